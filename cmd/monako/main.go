@@ -24,9 +24,11 @@ func parseCommandLine() (cliSettings compose.CommandLineSettings) {
 	var baseURL = f.String("base-url", "", "Custom base URL")
 	var trace = f.Bool("trace", false, "Enable trace logging")
 	var showVersion = f.Bool("version", false, "Show version")
-	var failOnHugoError = f.Bool("fail-on-error", false, "Fail on document conversion errors")
+	var failOnHugoError = f.Bool("fail-on-error", false, "Fail on document conversion errors and broken links")
 	var onlyCompose = f.Bool("compose", false, "Only compose the Monako structure")
 	var onlyRender = f.Bool("render", false, "Only render HTML files from an existing Monako structure")
+	var cacheDir = f.String("cache-dir", "", "Directory for caching cloned repositories between runs")
+	var concurrency = f.Int("concurrency", 0, "Number of origins to compose in parallel")
 
 	err := f.Parse(os.Args[1:])
 	if err != nil {
@@ -46,6 +48,8 @@ func parseCommandLine() (cliSettings compose.CommandLineSettings) {
 		FailOnHugoError:    *failOnHugoError,
 		OnlyCompose:        *onlyCompose,
 		OnlyRender:         *onlyRender,
+		CacheDir:           *cacheDir,
+		Concurrency:        *concurrency,
 	}
 }
 
@@ -85,6 +89,11 @@ func main() {
 		if cliSettings.FailOnHugoError && err != nil {
 			log.Fatal(err)
 		}
+
+		err = config.CheckLinks()
+		if cliSettings.FailOnHugoError && err != nil {
+			log.Fatal(err)
+		}
 	}
 
 }
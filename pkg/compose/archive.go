@@ -0,0 +1,234 @@
+package compose
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-billy.v4"
+	"gopkg.in/src-d/go-billy.v4/memfs"
+)
+
+// OriginTypeArchive is the type of origins that are read from a tar.gz, tar or zip archive
+const OriginTypeArchive = "archive"
+
+// defaultMaxArchiveSize is the maximum size of an archive and of its extracted files, if not configured
+const defaultMaxArchiveSize = 256 * 1024 * 1024
+
+// checksumPrefix is the optional prefix of the checksum of an archive
+const checksumPrefix = "sha256:"
+
+// OpenArchive downloads or opens the archive of the origin and returns its extracted files
+// as a virtual filesystem. The archive is verified against the configured checksum.
+func (origin *Origin) OpenArchive() (filesystem billy.Filesystem, err error) {
+
+	origin.printf("\nReading archive '%s' ...\n", origin.URL)
+
+	if origin.Branch != "" || origin.Tag != "" || origin.Commit != "" {
+		return nil, fmt.Errorf("Archive origin %s can't have a branch, tag or commit", origin.URL)
+	}
+
+	archive, err := origin.readArchive()
+	if err != nil {
+		return nil, err
+	}
+
+	err = origin.verifyChecksum(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	filesystem = memfs.New()
+	switch {
+	case bytes.HasPrefix(archive, []byte("PK\x03\x04")):
+		err = origin.extractZip(archive, filesystem)
+	case bytes.HasPrefix(archive, []byte{0x1f, 0x8b}):
+		var gzipReader *gzip.Reader
+		gzipReader, err = gzip.NewReader(bytes.NewReader(archive))
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error decompressing archive %s", origin.URL))
+		}
+		err = origin.extractTar(gzipReader, filesystem)
+	default:
+		err = origin.extractTar(bytes.NewReader(archive), filesystem)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error extracting archive %s", origin.URL))
+	}
+
+	origin.getLogger().Debugf("Extracted archive %s", origin.URL)
+	return filesystem, nil
+}
+
+// readArchive returns the content of the archive from an HTTP(S) URL or a local file
+func (origin *Origin) readArchive() ([]byte, error) {
+
+	if !strings.HasPrefix(origin.URL, "http://") && !strings.HasPrefix(origin.URL, "https://") {
+		f, err := os.Open(origin.URL)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error opening archive %s", origin.URL))
+		}
+		defer f.Close()
+		return origin.readLimited(f)
+	}
+
+	request, err := http.NewRequest(http.MethodGet, origin.URL, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error creating request for archive %s", origin.URL))
+	}
+	if origin.EnvUsername != "" || origin.EnvPassword != "" {
+		request.SetBasicAuth(os.Getenv(origin.EnvUsername), os.Getenv(origin.EnvPassword))
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error downloading archive %s", origin.URL))
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Error downloading archive %s: %s", origin.URL, response.Status)
+	}
+
+	if response.ContentLength > origin.getMaxArchiveSize() {
+		return nil, fmt.Errorf("Archive %s is larger than the maximum size of %d bytes", origin.URL, origin.getMaxArchiveSize())
+	}
+
+	return origin.readLimited(response.Body)
+}
+
+// readLimited reads the archive and fails if it exceeds the maximum archive size
+func (origin *Origin) readLimited(r io.Reader) ([]byte, error) {
+
+	content, err := ioutil.ReadAll(io.LimitReader(r, origin.getMaxArchiveSize()+1))
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error reading archive %s", origin.URL))
+	}
+	if int64(len(content)) > origin.getMaxArchiveSize() {
+		return nil, fmt.Errorf("Archive %s is larger than the maximum size of %d bytes", origin.URL, origin.getMaxArchiveSize())
+	}
+	return content, nil
+}
+
+// verifyChecksum compares the SHA-256 checksum of the archive with the configured one
+func (origin *Origin) verifyChecksum(archive []byte) error {
+
+	if origin.Checksum == "" {
+		origin.getLogger().Warnf("No checksum configured for archive %s, skipping verification", origin.URL)
+		return nil
+	}
+
+	sum := sha256.Sum256(archive)
+	actual := hex.EncodeToString(sum[:])
+	expected := strings.ToLower(strings.TrimPrefix(origin.Checksum, checksumPrefix))
+
+	if actual != expected {
+		return fmt.Errorf("Checksum mismatch for archive %s, expected %s but got %s%s", origin.URL, origin.Checksum, checksumPrefix, actual)
+	}
+	return nil
+}
+
+// extractTar extracts the regular files of a tar archive into the filesystem
+func (origin *Origin) extractTar(r io.Reader, filesystem billy.Filesystem) error {
+
+	extracted := int64(0)
+	tarReader := tar.NewReader(r)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error reading tar archive"))
+		}
+
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+			continue
+		}
+
+		extracted += header.Size
+		err = origin.extractFile(filesystem, header.Name, tarReader, extracted)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// extractZip extracts the regular files of a zip archive into the filesystem
+func (origin *Origin) extractZip(archive []byte, filesystem billy.Filesystem) error {
+
+	zipReader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error reading zip archive"))
+	}
+
+	extracted := int64(0)
+	for _, f := range zipReader.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+
+		extracted += int64(f.UncompressedSize64)
+		r, err := f.Open()
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error opening %s in zip archive", f.Name))
+		}
+		err = origin.extractFile(filesystem, f.Name, r, extracted)
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractFile writes a single file of an archive into the filesystem. Files outside of the
+// archive root and archives extracting to more than the maximum archive size are rejected.
+func (origin *Origin) extractFile(filesystem billy.Filesystem, name string, r io.Reader, extracted int64) error {
+
+	if extracted > origin.getMaxArchiveSize() {
+		return fmt.Errorf("Extracted files are larger than the maximum size of %d bytes", origin.getMaxArchiveSize())
+	}
+
+	slashName := strings.Replace(name, "\\", "/", -1)
+	if path.IsAbs(slashName) {
+		return fmt.Errorf("Invalid absolute file name '%s' in archive", name)
+	}
+	for _, element := range strings.Split(slashName, "/") {
+		if element == ".." {
+			return fmt.Errorf("Invalid file name '%s' outside of archive", name)
+		}
+	}
+
+	f, err := filesystem.Create(path.Clean(slashName))
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error creating %s", name))
+	}
+	defer f.Close()
+
+	// Limit the copy as well, since the size in the header can't be trusted
+	_, err = io.Copy(f, io.LimitReader(r, origin.getMaxArchiveSize()))
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error extracting %s", name))
+	}
+	return nil
+}
+
+// getMaxArchiveSize returns the maximum size of the archive and its extracted files in bytes
+func (origin *Origin) getMaxArchiveSize() int64 {
+	if origin.MaxArchiveSize > 0 {
+		return origin.MaxArchiveSize
+	}
+	return defaultMaxArchiveSize
+}
@@ -0,0 +1,177 @@
+package compose
+
+// run: go test ./pkg/compose -run TestArchive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testArchiveFiles = map[string]string{
+	"docs-1.0/docs/README.md":          "# Archived",
+	"docs-1.0/docs/images/logo.png":    "PNG",
+	"docs-1.0/docs/internal/notes.txt": "Not whitelisted",
+}
+
+func TestArchiveOrigin(t *testing.T) {
+
+	archives := map[string][]byte{
+		"/docs.tar.gz": createTestTarGz(t, testArchiveFiles),
+		"/docs.zip":    createTestZip(t, testArchiveFiles),
+		"/evil.tar.gz": createTestTarGz(t, map[string]string{"../../evil.md": "# Evil"}),
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		archive, exists := archives[r.URL.Path]
+		if !exists {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(archive)
+	}))
+	defer server.Close()
+
+	for _, name := range []string{"docs.tar.gz", "docs.zip"} {
+		t.Run("Compose "+name, func(t *testing.T) {
+			origin := NewOrigin(server.URL+"/"+name, "", "docs-1.0/docs", "docs/archived")
+			origin.Type = OriginTypeArchive
+			origin.Checksum = "sha256:" + getTestChecksum(archives["/"+name])
+			config, _ := getTestConfig(t, *origin)
+
+			err := config.Compose()
+			assert.NoError(t, err)
+
+			assert.FileExists(t, filepath.Join(config.ContentWorkingDir, "docs/archived/README.md"))
+			assert.FileExists(t, filepath.Join(config.ContentWorkingDir, "docs/archived/images/logo.png"))
+			assert.NoFileExists(t, filepath.Join(config.ContentWorkingDir, "docs/archived/internal/notes.txt"))
+		})
+	}
+
+	t.Run("Compose local archive", func(t *testing.T) {
+		archiveFile := filepath.Join(GetLocalTempDir(t), "docs.tar.gz")
+		assert.NoError(t, ioutil.WriteFile(archiveFile, archives["/docs.tar.gz"], standardFilemode))
+
+		origin := NewOrigin(archiveFile, "", "docs-1.0/docs", "docs/archived")
+		origin.Type = OriginTypeArchive
+		config, _ := getTestConfig(t, *origin)
+
+		err := config.Compose()
+		assert.NoError(t, err)
+		assert.FileExists(t, filepath.Join(config.ContentWorkingDir, "docs/archived/README.md"))
+	})
+
+	t.Run("Fail on checksum mismatch", func(t *testing.T) {
+		origin := NewOrigin(server.URL+"/docs.tar.gz", "", ".", "docs/archived")
+		origin.Type = OriginTypeArchive
+		origin.Checksum = getTestChecksum([]byte("other"))
+		config, _ := getTestConfig(t, *origin)
+
+		err := config.Compose()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "Checksum mismatch")
+	})
+
+	t.Run("Fail on too large archive", func(t *testing.T) {
+		origin := NewOrigin(server.URL+"/docs.zip", "", ".", "docs/archived")
+		origin.Type = OriginTypeArchive
+		origin.MaxArchiveSize = 10
+		config, _ := getTestConfig(t, *origin)
+
+		err := config.Compose()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "larger than the maximum size")
+	})
+
+	t.Run("Fail on too large extracted files", func(t *testing.T) {
+		large := createTestTarGz(t, map[string]string{"large.md": string(make([]byte, 100000))})
+		archiveFile := filepath.Join(GetLocalTempDir(t), "large.tar.gz")
+		assert.NoError(t, ioutil.WriteFile(archiveFile, large, standardFilemode))
+
+		origin := NewOrigin(archiveFile, "", ".", "docs/archived")
+		origin.Type = OriginTypeArchive
+		origin.MaxArchiveSize = 50000
+		config, _ := getTestConfig(t, *origin)
+
+		err := config.Compose()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "Extracted files are larger")
+	})
+
+	t.Run("Fail on files outside of archive", func(t *testing.T) {
+		origin := NewOrigin(server.URL+"/evil.tar.gz", "", ".", "docs/archived")
+		origin.Type = OriginTypeArchive
+		config, _ := getTestConfig(t, *origin)
+
+		err := config.Compose()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "outside of archive")
+	})
+
+	t.Run("Fail on missing archive", func(t *testing.T) {
+		origin := NewOrigin(server.URL+"/missing.zip", "", ".", "docs/archived")
+		origin.Type = OriginTypeArchive
+		config, _ := getTestConfig(t, *origin)
+
+		err := config.Compose()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "404")
+	})
+}
+
+// createTestTarGz returns a tar.gz archive containing the given files
+func createTestTarGz(t *testing.T, files map[string]string) []byte {
+
+	var buffer bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buffer)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	for name, content := range files {
+		err := tarWriter.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0600,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		})
+		assert.NoError(t, err)
+		_, err = tarWriter.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, tarWriter.Close())
+	assert.NoError(t, gzipWriter.Close())
+	return buffer.Bytes()
+}
+
+// createTestZip returns a zip archive containing the given files
+func createTestZip(t *testing.T, files map[string]string) []byte {
+
+	var buffer bytes.Buffer
+	zipWriter := zip.NewWriter(&buffer)
+
+	for name, content := range files {
+		w, err := zipWriter.Create(name)
+		assert.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, zipWriter.Close())
+	return buffer.Bytes()
+}
+
+// getTestChecksum returns the hex encoded SHA-256 checksum
+func getTestChecksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
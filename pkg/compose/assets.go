@@ -0,0 +1,113 @@
+package compose
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/snipem/monako/pkg/helpers"
+	"gopkg.in/src-d/go-billy.v4"
+)
+
+// missingAsset is a reference of a document to a file that doesn't exist
+type missingAsset struct {
+	document string
+	target   string
+}
+
+// addReferencedAssets replaces the assets selected by the whitelist with the files referenced by
+// the documents, whatever their extension. References to missing files of the doc dir, that are no
+// documents, are reported.
+func (origin *Origin) addReferencedAssets(files []OriginFile, filesystem billy.Filesystem) ([]OriginFile, error) {
+
+	var documents []OriginFile
+	for _, file := range files {
+		if file.GetFormat() != "" {
+			documents = append(documents, file)
+		}
+	}
+
+	assets, missing, err := origin.getReferencedAssets(documents, filesystem)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range missing {
+		origin.printf("Document '%s' references missing file '%s'\n", m.document, m.target)
+	}
+
+	return append(documents, assets...), nil
+}
+
+// getReferencedAssets returns the files that are referenced by the documents and are no documents
+// themselves, and the references to files that don't exist. Referenced files outside of the doc dir,
+// documents not selected by the whitelist, blacklisted or ignored files are not returned.
+func (origin *Origin) getReferencedAssets(documents []OriginFile, filesystem billy.Filesystem) (assets []OriginFile, missing []missingAsset, err error) {
+
+	isDocument := map[string]bool{}
+	for _, document := range documents {
+		isDocument[document.RemotePath] = true
+	}
+
+	found := map[string]bool{}
+	for _, document := range documents {
+
+		content, err := readFile(filesystem, document.RemotePath)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, fmt.Sprintf("Error reading markup file %s", document.RemotePath))
+		}
+
+		var targets []string
+		document.replaceLinks(string(content), func(macro string, target string) (string, string) {
+			targets = append(targets, target)
+			return macro, target
+		})
+
+		for _, target := range targets {
+			remotePath, _, ok := document.resolveLink(target)
+			if !ok || isDocument[remotePath] || found[remotePath] {
+				continue
+			}
+
+			// Documents are only selected by the whitelist, they are never composed as assets. Links to
+			// documents and to files outside of the doc dir are rewritten and checked like other links.
+			if helpers.IsMarkdown(remotePath) || helpers.IsAsciidoc(remotePath) || !origin.isInSourceDir(remotePath) {
+				continue
+			}
+
+			info, err := filesystem.Stat(remotePath)
+			if err != nil {
+				missing = append(missing, missingAsset{document: document.RemotePath, target: target})
+				continue
+			}
+			if info.IsDir() || origin.isIgnored(remotePath, false) || helpers.PathIsListed(remotePath, origin.FileBlacklist) {
+				continue
+			}
+
+			found[remotePath] = true
+			assets = append(assets, origin.newFile(remotePath))
+		}
+	}
+	return assets, missing, nil
+}
+
+// isInSourceDir returns true if the path of the repository is below the doc dir of the origin
+func (origin *Origin) isInSourceDir(remotePath string) bool {
+	sourceDir := path.Clean(origin.SourceDir)
+	if sourceDir == "." || sourceDir == "/" {
+		return true
+	}
+	return strings.HasPrefix(remotePath, strings.TrimPrefix(sourceDir, "/")+"/")
+}
+
+// readFile returns the content of a file of the virtual filesystem
+func readFile(filesystem billy.Filesystem, remotePath string) ([]byte, error) {
+	f, err := filesystem.Open(remotePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ioutil.ReadAll(f)
+}
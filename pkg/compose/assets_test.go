@@ -0,0 +1,70 @@
+package compose
+
+// run: go test ./pkg/compose -run TestReferencedAssets
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferencedAssets(t *testing.T) {
+
+	filesystem := createTestFilesystem(t, map[string]string{
+		"docs/README.md": "![Diagram](images/flow.drawio.svg)\n" +
+			"<img src=\"images/photo.webp\" width=\"100\">\n" +
+			"[Manual](manual.pdf) [Guide](guide.adoc) [Web](https://example.com/x.png)\n" +
+			"![Missing](images/missing.png) ![Outside](../logo.png) ![Secret](secret.key)\n" +
+			"[Changelog](CHANGELOG.asciidoc) [Removed](removed.md#usage) [Missing outside](../missing.png)\n" +
+			"```\n![Code](images/code.png)\n```",
+		"docs/guide.adoc":             "image::images/flow.drawio.svg[Flow]\nimage:icons/tip.png[Tip]",
+		"docs/images/flow.drawio.svg": "SVG",
+		"docs/images/photo.webp":      "WEBP",
+		"docs/images/unused.png":      "PNG",
+		"docs/images/code.png":        "PNG",
+		"docs/icons/tip.png":          "PNG",
+		"docs/manual.pdf":             "PDF",
+		"docs/secret.key":             "KEY",
+		"docs/CHANGELOG.asciidoc":     "= Changelog",
+		"logo.png":                    "PNG",
+	})
+
+	origin := NewOrigin("https://github.com/snipem/monako.git", "master", "docs", "docs/product")
+	origin.config = &Config{ContentWorkingDir: filepath.Join("compose", "content")}
+	origin.ReferencedAssets = true
+	origin.FileWhitelist = []string{".md", ".adoc", ".png"}
+	origin.FileBlacklist = []string{".key"}
+
+	files, err := origin.addReferencedAssets(origin.getMatchingFiles(origin.SourceDir, filesystem), filesystem)
+	assert.NoError(t, err)
+
+	var remotePaths []string
+	for _, file := range files {
+		remotePaths = append(remotePaths, file.RemotePath)
+	}
+	assert.ElementsMatch(t, []string{
+		"docs/README.md",
+		"docs/guide.adoc",
+		"docs/images/flow.drawio.svg",
+		"docs/images/photo.webp",
+		"docs/manual.pdf",
+		"docs/icons/tip.png",
+	}, remotePaths)
+
+	t.Run("Missing files", func(t *testing.T) {
+		_, missing, err := origin.getReferencedAssets(files[:2], filesystem)
+		assert.NoError(t, err)
+		assert.Equal(t, []missingAsset{{document: "docs/README.md", target: "images/missing.png"}}, missing)
+	})
+}
+
+func TestIsInSourceDir(t *testing.T) {
+	origin := NewOrigin("", "master", "docs/", "")
+	assert.True(t, origin.isInSourceDir("docs/images/a.png"))
+	assert.False(t, origin.isInSourceDir("docsother/a.png"))
+	assert.False(t, origin.isInSourceDir("logo.png"))
+
+	origin.SourceDir = "."
+	assert.True(t, origin.isInSourceDir("logo.png"))
+}
@@ -0,0 +1,112 @@
+package compose
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	gossh "golang.org/x/crypto/ssh"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/ssh"
+)
+
+// defaultSSHUser is used for SSH origins that don't contain a user, like ssh://host/repo.git
+const defaultSSHUser = "git"
+
+// getAuth returns the authentication method for the origin. SSH origins use a private key or
+// the ssh-agent, all other origins use basic auth based on the username and password stored in
+// the configured env variables
+func (origin *Origin) getAuth() (transport.AuthMethod, error) {
+
+	endpoint, err := transport.NewEndpoint(origin.URL)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error parsing origin URL %s", origin.URL))
+	}
+
+	if endpoint.Protocol == "ssh" {
+		return origin.getSSHAuth(endpoint.User)
+	}
+
+	basicauth := http.BasicAuth{}
+
+	username := os.Getenv(origin.EnvUsername)
+	password := os.Getenv(origin.EnvPassword)
+
+	if username != "" && password != "" {
+		origin.printf("Using username and password stored in env variables\n")
+		basicauth = http.BasicAuth{
+			Username: username,
+			Password: password,
+		}
+	}
+
+	return &basicauth, nil
+}
+
+// getSSHAuth returns the SSH authentication for the origin. A private key stored in an env variable
+// is preferred over a private key file. If none of them is configured, the ssh-agent is used.
+func (origin *Origin) getSSHAuth(user string) (transport.AuthMethod, error) {
+
+	if user == "" {
+		user = defaultSSHUser
+	}
+
+	hostKeyCallback, err := origin.getHostKeyCallback()
+	if err != nil {
+		return nil, err
+	}
+
+	passphrase := os.Getenv(origin.EnvSSHPassphrase)
+
+	if key := os.Getenv(origin.EnvSSHKey); origin.EnvSSHKey != "" && key != "" {
+		origin.printf("Using SSH key stored in env variable\n")
+		auth, err := ssh.NewPublicKeys(user, []byte(key), passphrase)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error reading SSH key from env variable %s", origin.EnvSSHKey))
+		}
+		auth.HostKeyCallback = hostKeyCallback
+		return auth, nil
+	}
+
+	if origin.SSHKey != "" {
+		origin.printf("Using SSH key '%s'\n", origin.SSHKey)
+		auth, err := ssh.NewPublicKeysFromFile(user, origin.SSHKey, passphrase)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error reading SSH key %s", origin.SSHKey))
+		}
+		auth.HostKeyCallback = hostKeyCallback
+		return auth, nil
+	}
+
+	origin.printf("Using ssh-agent\n")
+	auth, err := ssh.NewSSHAgentAuth(user)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error connecting to ssh-agent"))
+	}
+	auth.HostKeyCallback = hostKeyCallback
+	return auth, nil
+}
+
+// getHostKeyCallback returns the verification of SSH host keys. Hosts are verified against the
+// configured known_hosts file or the default known_hosts files of the user, unless verification
+// is explicitly disabled
+func (origin *Origin) getHostKeyCallback() (gossh.HostKeyCallback, error) {
+
+	if origin.InsecureIgnoreHostKey {
+		origin.printf("Warning: SSH host key verification is disabled for %s\n", origin.URL)
+		return gossh.InsecureIgnoreHostKey(), nil
+	}
+
+	var files []string
+	if origin.KnownHosts != "" {
+		files = append(files, origin.KnownHosts)
+	}
+
+	// Without files the default known_hosts files are used
+	callback, err := ssh.NewKnownHostsCallback(files...)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error reading known hosts %s", files))
+	}
+	return callback, nil
+}
@@ -0,0 +1,326 @@
+package compose
+
+// run: go test ./pkg/compose -run TestSSH
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"gopkg.in/src-d/go-billy.v4/osfs"
+	"gopkg.in/src-d/go-git.v4/plumbing/cache"
+	"gopkg.in/src-d/go-git.v4/plumbing/protocol/packp"
+	"gopkg.in/src-d/go-git.v4/plumbing/storer"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/server"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/ssh"
+	gitfs "gopkg.in/src-d/go-git.v4/storage/filesystem"
+)
+
+func TestSSHOrigin(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("Local repository paths can't be served via SSH URLs on Windows")
+	}
+
+	repoDir := createTestRepository(t, map[string]string{
+		"README.md": "# Served via SSH",
+	})
+
+	clientKey, clientPEM := generateTestKey(t)
+	address, hostKey := startTestSSHServer(t, clientKey.PublicKey())
+	tempdir := GetLocalTempDir(t)
+
+	absRepoDir, err := filepath.Abs(repoDir)
+	assert.NoError(t, err)
+	url := fmt.Sprintf("ssh://git@%s%s", address, filepath.ToSlash(absRepoDir))
+
+	knownHosts := filepath.Join(tempdir, "known_hosts")
+	err = ioutil.WriteFile(knownHosts, []byte(knownhosts.Line([]string{address}, hostKey)+"\n"), standardFilemode)
+	assert.NoError(t, err)
+
+	keyFile := filepath.Join(tempdir, "id_rsa")
+	err = ioutil.WriteFile(keyFile, clientPEM, standardFilemode)
+	assert.NoError(t, err)
+
+	t.Run("Clone with key file and known hosts", func(t *testing.T) {
+		origin := NewOrigin(url, "master", ".", "docs/ssh")
+		origin.config = &Config{}
+		origin.SSHKey = keyFile
+		origin.KnownHosts = knownHosts
+
+		filesystem, err := origin.CloneDir()
+		assert.NoError(t, err)
+		_, err = filesystem.Stat("README.md")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Clone with encrypted key in env variable", func(t *testing.T) {
+		block, err := x509.EncryptPEMBlock(rand.Reader, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(clientKey.key), []byte("secret"), x509.PEMCipherAES256)
+		assert.NoError(t, err)
+		os.Setenv("MONAKO_TEST_SSH_KEY", string(pem.EncodeToMemory(block)))
+		os.Setenv("MONAKO_TEST_SSH_PASSPHRASE", "secret")
+		defer os.Unsetenv("MONAKO_TEST_SSH_KEY")
+		defer os.Unsetenv("MONAKO_TEST_SSH_PASSPHRASE")
+
+		origin := NewOrigin(url, "master", ".", "docs/ssh")
+		origin.config = &Config{}
+		origin.EnvSSHKey = "MONAKO_TEST_SSH_KEY"
+		origin.EnvSSHPassphrase = "MONAKO_TEST_SSH_PASSPHRASE"
+		origin.KnownHosts = knownHosts
+
+		_, err = origin.CloneDir()
+		assert.NoError(t, err)
+	})
+
+	t.Run("Fail on unknown host key", func(t *testing.T) {
+		otherKnownHosts := filepath.Join(tempdir, "other_known_hosts")
+		otherKey, _ := generateTestKey(t)
+		err = ioutil.WriteFile(otherKnownHosts, []byte(knownhosts.Line([]string{address}, otherKey.PublicKey())+"\n"), standardFilemode)
+		assert.NoError(t, err)
+
+		origin := NewOrigin(url, "master", ".", "docs/ssh")
+		origin.config = &Config{}
+		origin.SSHKey = keyFile
+		origin.KnownHosts = otherKnownHosts
+
+		_, err := origin.CloneDir()
+		assert.Error(t, err)
+
+		t.Run("Unless host key verification is disabled", func(t *testing.T) {
+			origin.InsecureIgnoreHostKey = true
+			_, err := origin.CloneDir()
+			assert.NoError(t, err)
+		})
+	})
+
+	t.Run("Fail on unauthorized key", func(t *testing.T) {
+		_, otherPEM := generateTestKey(t)
+		otherKeyFile := filepath.Join(tempdir, "other_id_rsa")
+		err = ioutil.WriteFile(otherKeyFile, otherPEM, standardFilemode)
+		assert.NoError(t, err)
+
+		origin := NewOrigin(url, "master", ".", "docs/ssh")
+		origin.config = &Config{}
+		origin.SSHKey = otherKeyFile
+		origin.KnownHosts = knownHosts
+
+		_, err := origin.CloneDir()
+		assert.Error(t, err)
+	})
+}
+
+func TestGetAuth(t *testing.T) {
+
+	t.Run("HTTPS origins use basic auth", func(t *testing.T) {
+		os.Setenv("MONAKO_TEST_USER", "user")
+		os.Setenv("MONAKO_TEST_PASSWORD", "password")
+		defer os.Unsetenv("MONAKO_TEST_USER")
+		defer os.Unsetenv("MONAKO_TEST_PASSWORD")
+
+		origin := NewOrigin("https://github.com/snipem/monako-test.git", "master", ".", ".")
+		origin.EnvUsername = "MONAKO_TEST_USER"
+		origin.EnvPassword = "MONAKO_TEST_PASSWORD"
+
+		auth, err := origin.getAuth()
+		assert.NoError(t, err)
+		assert.Equal(t, &http.BasicAuth{Username: "user", Password: "password"}, auth)
+	})
+
+	t.Run("SCP like origins use SSH", func(t *testing.T) {
+		_, clientPEM := generateTestKey(t)
+		keyFile := filepath.Join(GetLocalTempDir(t), "id_rsa")
+		err := ioutil.WriteFile(keyFile, clientPEM, standardFilemode)
+		assert.NoError(t, err)
+
+		origin := NewOrigin("deploy@gitlab.example.com:org/repo.git", "master", ".", ".")
+		origin.SSHKey = keyFile
+		origin.InsecureIgnoreHostKey = true
+
+		auth, err := origin.getAuth()
+		assert.NoError(t, err)
+		assert.IsType(t, &ssh.PublicKeys{}, auth)
+		assert.Equal(t, "deploy", auth.(*ssh.PublicKeys).User)
+	})
+
+	t.Run("Fail on missing key file", func(t *testing.T) {
+		origin := NewOrigin("ssh://gitlab.example.com/org/repo.git", "master", ".", ".")
+		origin.SSHKey = "does/not/exist"
+		origin.InsecureIgnoreHostKey = true
+
+		_, err := origin.getAuth()
+		assert.Error(t, err)
+	})
+}
+
+// testKey is a RSA key used for the SSH tests
+type testKey struct {
+	key    *rsa.PrivateKey
+	signer gossh.Signer
+}
+
+// PublicKey returns the SSH public key of the test key
+func (k *testKey) PublicKey() gossh.PublicKey {
+	return k.signer.PublicKey()
+}
+
+// generateTestKey returns a new RSA key and its PEM encoding
+func generateTestKey(t *testing.T) (*testKey, []byte) {
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	signer, err := gossh.NewSignerFromKey(key)
+	assert.NoError(t, err)
+
+	return &testKey{key: key, signer: signer}, pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+}
+
+// startTestSSHServer starts an in-process SSH server serving git-upload-pack for local
+// repositories. Only the given public key is authorized. It returns the address of the
+// server and its host key.
+func startTestSSHServer(t *testing.T, authorizedKey gossh.PublicKey) (string, gossh.PublicKey) {
+
+	hostKey, _ := generateTestKey(t)
+
+	serverConfig := &gossh.ServerConfig{
+		PublicKeyCallback: func(conn gossh.ConnMetadata, key gossh.PublicKey) (*gossh.Permissions, error) {
+			if string(key.Marshal()) == string(authorizedKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unauthorized key for %s", conn.User())
+		},
+	}
+	serverConfig.AddHostKey(hostKey.signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestSSHConnection(conn, serverConfig)
+		}
+	}()
+
+	t.Cleanup(func() { listener.Close() })
+
+	return listener.Addr().String(), hostKey.PublicKey()
+}
+
+// serveTestSSHConnection handles a single SSH connection and serves git-upload-pack requests
+func serveTestSSHConnection(conn net.Conn, serverConfig *gossh.ServerConfig) {
+
+	_, channels, requests, err := gossh.NewServerConn(conn, serverConfig)
+	if err != nil {
+		return
+	}
+	go gossh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(gossh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer channel.Close()
+			for req := range channelRequests {
+				if req.Type != "exec" {
+					_ = req.Reply(false, nil)
+					continue
+				}
+				_ = req.Reply(true, nil)
+
+				status := uint32(0)
+				if err := serveTestUploadPack(channel, req.Payload); err != nil {
+					fmt.Fprintln(channel.Stderr(), err)
+					status = 1
+				}
+
+				exitStatus := make([]byte, 4)
+				binary.BigEndian.PutUint32(exitStatus, status)
+				_, _ = channel.SendRequest("exit-status", false, exitStatus)
+				return
+			}
+		}()
+	}
+}
+
+// testRepositoryLoader loads the Git directory of the non bare test repositories
+type testRepositoryLoader struct{}
+
+// Load returns the storage of the repository at the path of the endpoint
+func (testRepositoryLoader) Load(endpoint *transport.Endpoint) (storer.Storer, error) {
+	gitDir := filepath.Join(filepath.FromSlash(endpoint.Path), ".git")
+	if _, err := os.Stat(gitDir); err != nil {
+		return nil, transport.ErrRepositoryNotFound
+	}
+	return gitfs.NewStorage(osfs.New(gitDir), cache.NewObjectLRUDefault()), nil
+}
+
+// serveTestUploadPack serves a git-upload-pack command like "git-upload-pack '/path/to/repo'"
+func serveTestUploadPack(channel gossh.Channel, payload []byte) error {
+
+	// The payload is a SSH string, prefixed by its length
+	command := string(payload[4:])
+	if !strings.HasPrefix(command, transport.UploadPackServiceName) {
+		return fmt.Errorf("unsupported command %s", command)
+	}
+	repoPath := strings.Trim(strings.TrimPrefix(command, transport.UploadPackServiceName), " '")
+
+	endpoint, err := transport.NewEndpoint(repoPath)
+	if err != nil {
+		return err
+	}
+
+	session, err := server.NewServer(testRepositoryLoader{}).NewUploadPackSession(endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	advertisedRefs, err := session.AdvertisedReferences()
+	if err != nil {
+		return err
+	}
+	if err := advertisedRefs.Encode(channel); err != nil {
+		return err
+	}
+
+	request := packp.NewUploadPackRequest()
+	if err := request.Decode(channel); err != nil {
+		return err
+	}
+
+	response, err := session.UploadPack(context.Background(), request)
+	if err != nil {
+		return err
+	}
+	return response.Encode(channel)
+}
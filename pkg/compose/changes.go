@@ -0,0 +1,279 @@
+package compose
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// recentChangesName is the name of the generated page and feed of recent changes
+const recentChangesName = "recent-changes"
+
+// feedTagPrefix is the prefix of the tag URIs identifying the feed and its entries without base URL,
+// see https://tools.ietf.org/html/rfc4151
+const feedTagPrefix = "tag:monako,2020:"
+
+// recentChangesLength is the number of documents listed in the recent changes
+const recentChangesLength = 50
+
+// recentChange is the last change of a composed document
+type recentChange struct {
+	// Origin is the source of the origin of the document
+	Origin string
+	// Title is the title of the document
+	Title string
+	// Page is the path of the document in the content dir, used for relrefs
+	Page string
+	// URL is the URL of the rendered document
+	URL string
+
+	Commit    *OriginFileCommit
+	CommitURL string
+}
+
+// atomFeed is an Atom feed, see https://tools.ietf.org/html/rfc4287
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// atomEntry is an entry of an Atom feed
+type atomEntry struct {
+	Title    string       `xml:"title"`
+	ID       string       `xml:"id"`
+	Updated  string       `xml:"updated"`
+	Links    []atomLink   `xml:"link"`
+	Author   atomAuthor   `xml:"author"`
+	Category atomCategory `xml:"category"`
+	Summary  string       `xml:"summary"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name  string `xml:"name"`
+	Email string `xml:"email,omitempty"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// writeRecentChanges writes a page and an Atom feed of the documents changed last across all origins
+func (config *Config) writeRecentChanges() error {
+
+	if config.DisableCommitInfo || config.DisableRecentChanges {
+		return nil
+	}
+
+	changes, err := config.getRecentChanges()
+	if err != nil {
+		return err
+	}
+
+	err = config.writeRecentChangesPage(changes)
+	if err != nil {
+		return err
+	}
+
+	return config.writeRecentChangesFeed(changes)
+}
+
+// getRecentChanges returns the documents changed last, newest first. Documents of several
+// versions changed by the same commit are listed once.
+func (config *Config) getRecentChanges() ([]recentChange, error) {
+
+	var changes []recentChange
+	seen := map[string]bool{}
+
+	for i := range config.Origins {
+		origin := &config.Origins[i]
+		for j := range origin.Files {
+			file := &origin.Files[j]
+			if file.Commit == nil || file.GetFormat() == "" {
+				continue
+			}
+
+			remote, remotePath, _ := file.getGitLocation()
+			key := remote + "\x00" + remotePath + "\x00" + file.Commit.Hash
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			title, err := getDocumentTitle(file.LocalPath)
+			if err != nil {
+				return nil, err
+			}
+
+			page, err := filepath.Rel(config.ContentWorkingDir, file.LocalPath)
+			if err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("Error getting page of %s", file.LocalPath))
+			}
+
+			changes = append(changes, recentChange{
+				Origin:    origin.URL,
+				Title:     title,
+				Page:      "/" + filepath.ToSlash(page),
+				URL:       getPageURL(config.ContentWorkingDir, file.LocalPath),
+				Commit:    file.Commit,
+				CommitURL: origin.getForge(remote).getCommitURL(file.Commit.Hash),
+			})
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Commit.Date.After(changes[j].Commit.Date)
+	})
+
+	if len(changes) > recentChangesLength {
+		changes = changes[:recentChangesLength]
+	}
+	return changes, nil
+}
+
+// getDocumentTitle returns the title of the frontmatter of a composed document or its file name
+func getDocumentTitle(localPath string) (string, error) {
+
+	frontmatter, err := readFrontmatter(localPath)
+	if err != nil {
+		return "", err
+	}
+
+	if title, found := getFrontmatterValue(frontmatter, "title"); found && fmt.Sprint(title) != "" {
+		return fmt.Sprint(title), nil
+	}
+
+	name := filepath.Base(localPath)
+	return strings.TrimSuffix(name, filepath.Ext(name)), nil
+}
+
+// writeRecentChangesPage writes the page listing the recent changes to the content dir
+func (config *Config) writeRecentChangesPage(changes []recentChange) error {
+
+	var page strings.Builder
+	page.WriteString("---\ntitle: Recent Changes\n---\n\n")
+	// The feed is next to the page, a relative link keeps the path of the base URL
+	fmt.Fprintf(&page, "Subscribe to the [feed of recent changes](%s).\n\n", recentChangesName+".atom")
+
+	for _, change := range changes {
+		commit := shortHash(change.Commit.Hash)
+		if change.CommitURL != "" {
+			commit = fmt.Sprintf("[%s](%s)", commit, change.CommitURL)
+		}
+		fmt.Fprintf(&page, "* [%s]({{< relref \"%s\" >}}) of %s, by %s on %s (%s)\n",
+			escapeMenuLabel(change.Title),
+			change.Page,
+			escapeMenuLabel(change.Origin),
+			escapeMenuLabel(change.Commit.Author.Name),
+			change.Commit.Date.Format("Jan 2, 2006"),
+			commit,
+		)
+	}
+
+	pageFile := filepath.Join(config.ContentWorkingDir, recentChangesName+".md")
+	err := createParentDir(pageFile)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error creating dir for %s", pageFile))
+	}
+
+	err = ioutil.WriteFile(pageFile, []byte(page.String()), standardFilemode)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error writing recent changes to %s", pageFile))
+	}
+	return nil
+}
+
+// writeRecentChangesFeed writes the Atom feed of the recent changes to the static dir of Hugo
+func (config *Config) writeRecentChangesFeed(changes []recentChange) error {
+
+	baseURL := strings.TrimSuffix(config.BaseURL, "/")
+
+	feed := atomFeed{
+		Title:   config.Title + " - Recent Changes",
+		ID:      config.getFeedID("/" + recentChangesName + ".atom"),
+		Links:   []atomLink{{Href: baseURL + "/" + recentChangesName + ".html"}},
+		Updated: time.Now().Format(time.RFC3339),
+	}
+	if isAbsoluteURL(baseURL) {
+		// The self link has to be absolute
+		feed.Links = append(feed.Links, atomLink{Href: baseURL + "/" + recentChangesName + ".atom", Rel: "self"})
+	}
+	if len(changes) > 0 {
+		feed.Updated = changes[0].Commit.Date.Format(time.RFC3339)
+	}
+
+	for _, change := range changes {
+		links := []atomLink{{Href: baseURL + change.URL}}
+		if change.CommitURL != "" {
+			links = append(links, atomLink{Href: change.CommitURL, Rel: "related"})
+		}
+		feed.Entries = append(feed.Entries, atomEntry{
+			Title:   change.Title,
+			ID:      config.getFeedID(change.URL + "#" + change.Commit.Hash),
+			Updated: change.Commit.Date.Format(time.RFC3339),
+			Links:   links,
+			Author: atomAuthor{
+				Name:  change.Commit.Author.Name,
+				Email: config.getPublicEmail(change.Commit.Author.Email),
+			},
+			Category: atomCategory{Term: change.Origin},
+			Summary:  change.Commit.Subject,
+		})
+	}
+
+	content, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error marshalling feed of recent changes"))
+	}
+
+	feedFile := filepath.Join(config.HugoWorkingDir, "static", recentChangesName+".atom")
+	err = createParentDir(feedFile)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error creating dir for %s", feedFile))
+	}
+
+	err = ioutil.WriteFile(feedFile, append([]byte(xml.Header), content...), standardFilemode)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error writing feed of recent changes to %s", feedFile))
+	}
+	return nil
+}
+
+// getFeedID returns the ID of a path in the feed. Atom IDs have to be absolute, without an absolute
+// base URL a tag URI is used.
+func (config *Config) getFeedID(path string) string {
+	baseURL := strings.TrimSuffix(config.BaseURL, "/")
+	if isAbsoluteURL(baseURL) {
+		return baseURL + path
+	}
+	return feedTagPrefix + path
+}
+
+// isAbsoluteURL returns true if the URL has a scheme and a host
+func isAbsoluteURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// shortHash returns the abbreviated hash of a commit
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
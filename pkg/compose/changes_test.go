@@ -0,0 +1,102 @@
+package compose
+
+// run: go test ./pkg/compose -run TestRecentChanges
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+func TestRecentChanges(t *testing.T) {
+
+	firstDir := createTestRepository(t, map[string]string{
+		"docs/guide.md": "---\ntitle: The Guide [Draft]\n---\n# Guide",
+	})
+	secondDir := createTestRepositoryAt(t, filepath.Join(GetLocalTempDir(t), "second"), map[string]string{
+		"manual.adoc": "= Manual",
+	})
+	commitTestFilesAs(t, secondDir, object.Signature{
+		Name:  "Jane Doe",
+		Email: "jane@example.com",
+		When:  time.Now().Add(time.Hour),
+	}, map[string]string{
+		"manual.adoc": "= Manual\n\nSecond edition",
+		"image.png":   "PNG",
+	})
+
+	config, _ := getTestConfig(t,
+		*NewOrigin(firstDir, "master", "docs", "docs/first"),
+		*NewOrigin(secondDir, "master", ".", "docs/second"),
+	)
+	config.EmailPrivacy = EmailPrivacyHide
+
+	err := config.Compose()
+	assert.NoError(t, err)
+
+	t.Run("Page", func(t *testing.T) {
+		page, err := ioutil.ReadFile(filepath.Join(config.ContentWorkingDir, recentChangesName+".md"))
+		assert.NoError(t, err)
+		assert.Contains(t, string(page), "title: Recent Changes")
+		assert.Contains(t, string(page), "[The Guide \\[Draft\\]]({{< relref \"/docs/first/guide.md\" >}}) of "+firstDir+", by Monako Test")
+		assert.Contains(t, string(page), "[Manual]({{< relref \"/docs/second/manual.adoc\" >}}) of "+secondDir+", by Jane Doe")
+		assert.NotContains(t, string(page), "image.png", "Only documents are listed")
+		assert.Contains(t, string(page), "[feed of recent changes](recent-changes.atom)", "Feed is linked relative to the page")
+		assert.NotContains(t, string(page), "]()", "Commits without forge are not linked")
+	})
+
+	t.Run("Feed", func(t *testing.T) {
+		content, err := ioutil.ReadFile(filepath.Join(config.HugoWorkingDir, "static", recentChangesName+".atom"))
+		assert.NoError(t, err)
+		assert.NotContains(t, string(content), "jane@example.com", "Email privacy applies to the feed")
+
+		var feed atomFeed
+		assert.NoError(t, xml.Unmarshal(content, &feed))
+		assert.Equal(t, "Test Config Title - Recent Changes", feed.Title)
+		assert.Len(t, feed.Entries, 2)
+
+		entry := feed.Entries[0]
+		assert.Equal(t, "Manual", entry.Title, "Newest change first, titled by its heading")
+		assert.Equal(t, "http://exampleurl.com/docs/second/manual.html", entry.Links[0].Href)
+		assert.Equal(t, "Jane Doe", entry.Author.Name)
+		assert.Equal(t, secondDir, entry.Category.Term)
+		assert.Equal(t, "Add test files", entry.Summary)
+		assert.Equal(t, feed.Updated, entry.Updated)
+		assert.Equal(t, "http://exampleurl.com/recent-changes.atom", feed.ID)
+		for _, link := range entry.Links {
+			assert.NotEmpty(t, link.Href, "Commits without forge are not linked")
+		}
+	})
+
+	t.Run("Feed without base URL", func(t *testing.T) {
+		config.BaseURL = ""
+		defer func() { config.BaseURL = "http://exampleurl.com" }()
+		assert.NoError(t, config.writeRecentChanges())
+
+		content, err := ioutil.ReadFile(filepath.Join(config.HugoWorkingDir, "static", recentChangesName+".atom"))
+		assert.NoError(t, err)
+
+		var feed atomFeed
+		assert.NoError(t, xml.Unmarshal(content, &feed))
+		assert.Equal(t, "tag:monako,2020:/recent-changes.atom", feed.ID, "IDs are absolute")
+		assert.True(t, strings.HasPrefix(feed.Entries[0].ID, "tag:monako,2020:/docs/second/manual.html#"))
+		for _, link := range feed.Links {
+			assert.NotEqual(t, "self", link.Rel, "Self link has to be absolute")
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		config, _ := getTestConfig(t, *NewOrigin(firstDir, "master", "docs", "docs/first"))
+		config.DisableRecentChanges = true
+
+		err := config.Compose()
+		assert.NoError(t, err)
+		assert.NoFileExists(t, filepath.Join(config.ContentWorkingDir, recentChangesName+".md"))
+	})
+}
@@ -0,0 +1,334 @@
+package compose
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// linkAttributes are the elements and attributes of the rendered pages that link to other files
+var linkAttributes = []struct {
+	selector  string
+	attribute string
+}{
+	{"a[href]", "href"},
+	{"img[src]", "src"},
+	{"source[src]", "src"},
+	{"script[src]", "src"},
+	{"link[href]", "href"},
+}
+
+// externalLinkTimeout is the timeout for requesting an external link
+const externalLinkTimeout = 10 * time.Second
+
+// LinkProblem is a broken link in a rendered page
+type LinkProblem struct {
+	// Page is the path of the rendered page in the public dir
+	Page string
+	// Link is the broken link as written in the page
+	Link string
+	// Reason describes why the link is broken
+	Reason string
+
+	// Origin is the source of the origin of the page, empty for generated pages
+	Origin string
+	// RemotePath is the path of the document in the origin
+	RemotePath string
+}
+
+// String returns the problem as a line of the report
+func (problem LinkProblem) String() string {
+	source := "generated page"
+	if problem.Origin != "" {
+		source = fmt.Sprintf("%s in %s", problem.RemotePath, problem.Origin)
+	}
+	return fmt.Sprintf("%s (%s): %s %s", problem.Page, source, problem.Link, problem.Reason)
+}
+
+// linkChecker checks the links of the rendered pages in the public dir
+type linkChecker struct {
+	publicDir string
+	basePath  string
+	baseURL   *url.URL
+
+	// files are all rendered files by their slash separated path in the public dir
+	files map[string]bool
+	// anchors are the ids of the elements of each page
+	anchors map[string]map[string]bool
+	// externalHosts are the glob patterns of hosts of external links that are requested
+	externalHosts []string
+	// external are the results of requested external links
+	external map[string]string
+	client   *http.Client
+}
+
+// CheckLinks checks the internal links, images and anchors of the rendered pages. Links to the
+// external hosts of the config are requested. A report of all problems is printed and an error
+// is returned if there are problems.
+func (config *Config) CheckLinks() error {
+
+	if config.DisableLinkCheck {
+		return nil
+	}
+
+	problems, err := config.findLinkProblems()
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error checking links"))
+	}
+
+	if len(problems) == 0 {
+		fmt.Println("Found no broken links")
+		return nil
+	}
+
+	fmt.Printf("\nFound %d broken links:\n", len(problems))
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	return fmt.Errorf("Found %d broken links", len(problems))
+}
+
+// findLinkProblems returns the broken links of the rendered pages, sorted by page
+func (config *Config) findLinkProblems() ([]LinkProblem, error) {
+
+	baseURL, err := url.Parse(config.BaseURL)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error parsing base URL %s", config.BaseURL))
+	}
+
+	checker := &linkChecker{
+		publicDir:     filepath.Join(config.HugoWorkingDir, "public"),
+		basePath:      "/" + strings.Trim(baseURL.Path, "/"),
+		baseURL:       baseURL,
+		files:         map[string]bool{},
+		anchors:       map[string]map[string]bool{},
+		externalHosts: config.CheckExternalLinks,
+		external:      map[string]string{},
+		client:        &http.Client{Timeout: externalLinkTimeout},
+	}
+
+	documents, err := checker.readPages()
+	if err != nil {
+		return nil, err
+	}
+
+	sources := config.getPageSources()
+
+	var problems []LinkProblem
+	for _, page := range sortedKeys(documents) {
+		for _, attribute := range linkAttributes {
+			documents[page].Find(attribute.selector).Each(func(_ int, element *goquery.Selection) {
+				link, _ := element.Attr(attribute.attribute)
+				reason := checker.checkLink(page, link)
+				if reason == "" {
+					return
+				}
+				problem := LinkProblem{Page: page, Link: link, Reason: reason}
+				if source, found := sources["/"+page]; found && source.parentOrigin != nil {
+					problem.Origin, _, _ = source.parentOrigin.getComposedLocation(source.RemotePath)
+					problem.RemotePath = source.RemotePath
+				}
+				problems = append(problems, problem)
+			})
+		}
+	}
+	return problems, nil
+}
+
+// readPages reads all files of the public dir and parses the HTML pages with their anchors
+func (checker *linkChecker) readPages() (map[string]*goquery.Document, error) {
+
+	documents := map[string]*goquery.Document{}
+
+	err := filepath.Walk(checker.publicDir, func(localPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(checker.publicDir, localPath)
+		if err != nil {
+			return err
+		}
+		page := filepath.ToSlash(relativePath)
+		checker.files[page] = true
+
+		if path.Ext(page) != ".html" {
+			return nil
+		}
+
+		f, err := os.Open(localPath)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error opening page %s", localPath))
+		}
+		defer f.Close()
+
+		document, err := goquery.NewDocumentFromReader(f)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error parsing page %s", localPath))
+		}
+		documents[page] = document
+
+		anchors := map[string]bool{}
+		document.Find("[id], a[name]").Each(func(_ int, element *goquery.Selection) {
+			if id, exists := element.Attr("id"); exists {
+				anchors[id] = true
+			}
+			if name, exists := element.Attr("name"); exists {
+				anchors[name] = true
+			}
+		})
+		checker.anchors[page] = anchors
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error reading rendered pages in %s", checker.publicDir))
+	}
+	return documents, nil
+}
+
+// checkLink returns the reason why the link of the page is broken or an empty string
+func (checker *linkChecker) checkLink(page string, link string) string {
+
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return "is not a valid URL"
+	}
+
+	// Links with the base URL are internal links
+	if u.Host != "" && u.Host == checker.baseURL.Host {
+		u.Scheme, u.Host = "", ""
+	}
+
+	switch {
+	case u.Scheme == "http" || u.Scheme == "https" || (u.Scheme == "" && u.Host != ""):
+		return checker.checkExternalLink(u)
+	case u.Scheme != "":
+		// Links like mailto: and data: are not checked
+		return ""
+	}
+
+	target := page
+	if u.Path != "" {
+		target = checker.resolvePath(page, u.Path)
+		if target == "" {
+			return "points to a missing file"
+		}
+	}
+
+	if u.Fragment == "" || path.Ext(target) != ".html" {
+		return ""
+	}
+	if !checker.anchors[target][u.Fragment] {
+		return fmt.Sprintf("points to a missing anchor in %s", target)
+	}
+	return ""
+}
+
+// resolvePath returns the rendered file of an internal link path of the page or an empty string
+// if it doesn't exist. Directories are served by their index page.
+func (checker *linkChecker) resolvePath(page string, linkPath string) string {
+
+	var target string
+	if strings.HasPrefix(linkPath, "/") {
+		// Absolute links contain the path of the base URL
+		if !strings.HasPrefix(linkPath+"/", strings.TrimSuffix(checker.basePath, "/")+"/") {
+			return ""
+		}
+		target = strings.TrimPrefix(linkPath, strings.TrimSuffix(checker.basePath, "/"))
+	} else {
+		target = path.Join(path.Dir("/"+page), linkPath)
+	}
+	target = strings.Trim(path.Clean("/"+target), "/")
+
+	for _, candidate := range []string{target, path.Join(target, "index.html"), target + ".html"} {
+		if checker.files[candidate] {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// checkExternalLink requests external links to the configured hosts and returns the reason why
+// the link is broken or an empty string
+func (checker *linkChecker) checkExternalLink(u *url.URL) string {
+
+	if !matchesHost(u.Hostname(), checker.externalHosts) {
+		return ""
+	}
+	if u.Scheme == "" {
+		u.Scheme = "https"
+	}
+	u.Fragment = ""
+	link := u.String()
+
+	if reason, checked := checker.external[link]; checked {
+		return reason
+	}
+
+	log.Debugf("Requesting external link %s", link)
+	reason := ""
+	response, err := checker.client.Head(link)
+	if err == nil && response.StatusCode == http.StatusMethodNotAllowed {
+		// Some servers don't support HEAD requests
+		response.Body.Close()
+		response, err = checker.client.Get(link)
+	}
+	if err != nil {
+		reason = fmt.Sprintf("can't be requested: %s", err)
+	} else {
+		response.Body.Close()
+		if response.StatusCode >= 400 {
+			reason = fmt.Sprintf("returns status %d", response.StatusCode)
+		}
+	}
+
+	checker.external[link] = reason
+	return reason
+}
+
+// matchesHost returns true if the host matches one of the glob patterns like "*.example.com"
+func matchesHost(host string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(host)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// getPageSources returns the composed documents by the URL of their rendered page
+func (config *Config) getPageSources() map[string]*OriginFile {
+	sources := map[string]*OriginFile{}
+	for i := range config.Origins {
+		for j := range config.Origins[i].Files {
+			file := &config.Origins[i].Files[j]
+			if file.GetFormat() != "" {
+				sources[getPageURL(config.ContentWorkingDir, file.LocalPath)] = file
+			}
+		}
+	}
+	return sources
+}
+
+// sortedKeys returns the sorted pages of the documents
+func sortedKeys(documents map[string]*goquery.Document) []string {
+	var pages []string
+	for page := range documents {
+		pages = append(pages, page)
+	}
+	sort.Strings(pages)
+	return pages
+}
@@ -0,0 +1,97 @@
+package compose
+
+// run: go test ./pkg/compose -run TestCheckLinks
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckLinks(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ok" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	assert.NoError(t, err)
+
+	origin := *NewOrigin("https://github.com/snipem/monako.git", "master", "docs", "docs/product")
+	config, _ := getTestConfig(t, origin)
+	config.BaseURL = "http://exampleurl.com/book/"
+	config.CheckExternalLinks = []string{serverURL.Hostname()}
+	config.Origins[0].resolvedCommit = "abc"
+	config.Origins[0].Files = []OriginFile{{
+		RemotePath:   "docs/guide.md",
+		LocalPath:    filepath.Join(config.ContentWorkingDir, "docs", "product", "guide.md"),
+		parentOrigin: &config.Origins[0],
+	}}
+
+	writePublicFile(t, config, "index.html", `<a href="docs/product/guide.html#setup">Guide</a>`)
+	writePublicFile(t, config, "docs/product/image.png", "PNG")
+	writePublicFile(t, config, "docs/product/api/index.html", `<h1 id="api">API</h1>`)
+	writePublicFile(t, config, "docs/product/guide.html", `
+		<h2 id="setup">Setup</h2>
+		<img src="image.png">
+		<a href="/book/docs/product/api/#api">API</a>
+		<a href="http://exampleurl.com/book/docs/product/guide.html">Self</a>
+		<a href="mailto:docs@example.com">Mail</a>
+		<a href="https://example.com/unchecked">Unchecked</a>
+		<a href="`+server.URL+`/ok">OK</a>
+		<a href="#setup">Setup</a>
+		<img src="missing.png">
+		<a href="api/index.html#usage">Usage</a>
+		<a href="/docs/product/guide.html">Outside of base URL</a>
+		<a href="`+server.URL+`/missing">Missing</a>`)
+
+	problems, err := config.findLinkProblems()
+	assert.NoError(t, err)
+
+	var links []string
+	for _, problem := range problems {
+		links = append(links, problem.Link)
+		assert.Equal(t, "docs/product/guide.html", problem.Page)
+		assert.Equal(t, "https://github.com/snipem/monako.git", problem.Origin)
+		assert.Equal(t, "docs/guide.md", problem.RemotePath)
+	}
+	assert.Equal(t, []string{
+		"api/index.html#usage",
+		"/docs/product/guide.html",
+		server.URL + "/missing",
+		"missing.png",
+	}, links)
+
+	t.Run("Report", func(t *testing.T) {
+		assert.Equal(t, "docs/product/guide.html (docs/guide.md in https://github.com/snipem/monako.git): missing.png points to a missing file", problems[3].String())
+		assert.Equal(t, "index.html (generated page): x points to a missing file", LinkProblem{Page: "index.html", Link: "x", Reason: "points to a missing file"}.String())
+
+		err := config.CheckLinks()
+		assert.EqualError(t, err, "Found 4 broken links")
+
+		config.DisableLinkCheck = true
+		assert.NoError(t, config.CheckLinks())
+	})
+}
+
+func TestMatchesHost(t *testing.T) {
+	assert.True(t, matchesHost("docs.example.com", []string{"*.example.com"}))
+	assert.True(t, matchesHost("Example.com", []string{"example.com"}))
+	assert.False(t, matchesHost("example.com", []string{"*.example.com"}))
+	assert.False(t, matchesHost("example.com", nil))
+}
+
+// writePublicFile writes a rendered file to the public dir of the config
+func writePublicFile(t *testing.T, config *Config, name string, content string) {
+	localPath := filepath.Join(config.HugoWorkingDir, "public", filepath.FromSlash(name))
+	assert.NoError(t, os.MkdirAll(filepath.Dir(localPath), standardFilemode))
+	assert.NoError(t, ioutil.WriteFile(localPath, []byte(content), standardFilemode))
+}
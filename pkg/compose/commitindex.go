@@ -0,0 +1,327 @@
+package compose
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gohugoio/hugo/hugofs/files"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/storer"
+)
+
+// commitIndex maps the paths of a repository to the last commit that changed them.
+// It is built by walking the history of the repository only once for all paths.
+type commitIndex struct {
+	last map[string]*OriginFileCommit
+
+	// contributors are the contributors of each path by their identity
+	contributors map[string]map[string]*OriginFileContributor
+
+	// mailmap maps the identities of commits to their authors
+	mailmap *mailmap
+}
+
+// commitIndexOptions select what is collected besides the last commit of each path
+type commitIndexOptions struct {
+	// contributors collects the first commit date and all contributors of each path
+	contributors bool
+	// historyLength is the number of latest commits collected for each path
+	historyLength int
+}
+
+// newCommitIndex walks the history of the repository from HEAD, newest commits first. With
+// contributors, the whole history is walked to find the first commit and all contributors of
+// every path. Otherwise the walk stops as soon as the last commits of every path are found.
+func newCommitIndex(repo *git.Repository, paths []string, options commitIndexOptions) (*commitIndex, error) {
+
+	index := &commitIndex{
+		last:         map[string]*OriginFileCommit{},
+		contributors: map[string]map[string]*OriginFileContributor{},
+	}
+
+	wanted := map[string]bool{}
+	remaining := map[string]bool{}
+	for _, p := range paths {
+		wanted[p] = true
+		remaining[p] = true
+	}
+	if len(remaining) == 0 {
+		return index, nil
+	}
+	if !options.contributors {
+		// Paths with all of their last commits are not needed anymore
+		wanted = remaining
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error resolving HEAD"))
+	}
+
+	index.mailmap, err = readMailmap(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	commits, err := repo.Log(&git.LogOptions{
+		From:  head.Hash(),
+		Order: git.LogOrderCommitterTime,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error opening git log"))
+	}
+	defer commits.Close()
+
+	err = commits.ForEach(func(commit *object.Commit) error {
+
+		changed, err := getChangedPaths(commit, wanted)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error getting changes of commit %s", commit.Hash))
+		}
+
+		for _, p := range changed {
+			last := index.last[p]
+			if last == nil {
+				last = newOriginFileCommit(commit, index.mailmap)
+				index.last[p] = last
+			}
+			if len(last.History) < options.historyLength {
+				last.History = append(last.History, newOriginFileHistoryCommit(commit, index.mailmap))
+			}
+			if len(last.History) >= options.historyLength {
+				delete(remaining, p)
+			}
+			if options.contributors {
+				index.addContributor(p, commit)
+			}
+		}
+
+		if !options.contributors && len(remaining) == 0 {
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error walking git log"))
+	}
+
+	index.sortContributors()
+
+	log.Debugf("Indexed commits of %d paths, %d paths not found in git log", len(index.last), len(paths)-len(index.last))
+	return index, nil
+}
+
+// addContributor adds an older commit to the first commit date and the contributors of the path
+func (index *commitIndex) addContributor(p string, commit *object.Commit) {
+
+	last := index.last[p]
+	if last.FirstDate.IsZero() || commit.Author.When.Before(last.FirstDate) {
+		last.FirstDate = commit.Author.When
+	}
+
+	if index.contributors[p] == nil {
+		index.contributors[p] = map[string]*OriginFileContributor{}
+	}
+
+	// Authors are identified by their email, the name of their latest commit is used
+	author := index.mailmap.getAuthor(commit)
+	identity := strings.ToLower(author.Email)
+	if identity == "" {
+		identity = author.Name
+	}
+
+	contributor, exists := index.contributors[p][identity]
+	if !exists {
+		contributor = &OriginFileContributor{
+			Name:  author.Name,
+			Email: author.Email,
+		}
+		index.contributors[p][identity] = contributor
+	}
+	contributor.Commits++
+}
+
+// sortContributors stores the contributors in the commit info of each path, the contributors
+// with the most commits first
+func (index *commitIndex) sortContributors() {
+	for p, byIdentity := range index.contributors {
+
+		contributors := make([]OriginFileContributor, 0, len(byIdentity))
+		for _, contributor := range byIdentity {
+			contributors = append(contributors, *contributor)
+		}
+
+		sort.Slice(contributors, func(i, j int) bool {
+			if contributors[i].Commits != contributors[j].Commits {
+				return contributors[i].Commits > contributors[j].Commits
+			}
+			return contributors[i].Name < contributors[j].Name
+		})
+		index.last[p].Contributors = contributors
+	}
+}
+
+// getChangedPaths returns the wanted paths that are changed by the commit. Like git log, a
+// merge commit only changes a path if it differs from all of its parents.
+func getChangedPaths(commit *object.Commit, wanted map[string]bool) ([]string, error) {
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	// The root commit adds all of its files
+	if commit.NumParents() == 0 {
+		var added []string
+		for p := range wanted {
+			if _, err := tree.FindEntry(p); err == nil {
+				added = append(added, p)
+			}
+		}
+		return added, nil
+	}
+
+	counts := map[string]int{}
+	err = commit.Parents().ForEach(func(parent *object.Commit) error {
+		parentTree, err := parent.Tree()
+		if err != nil {
+			return err
+		}
+
+		changes, err := object.DiffTree(parentTree, tree)
+		if err != nil {
+			return err
+		}
+
+		for _, change := range changes {
+			// Renamed or deleted files are known by their old name, added files by their new name
+			for _, name := range uniqueNames(change.From.Name, change.To.Name) {
+				if wanted[name] {
+					counts[name]++
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for p, count := range counts {
+		if count == commit.NumParents() {
+			changed = append(changed, p)
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// uniqueNames returns the non empty, distinct names
+func uniqueNames(from string, to string) []string {
+	switch {
+	case from == "" || from == to:
+		return []string{to}
+	case to == "":
+		return []string{from}
+	default:
+		return []string{from, to}
+	}
+}
+
+// getCommitSubject returns the first line of the commit message
+func getCommitSubject(commit *object.Commit) string {
+	return strings.SplitN(strings.TrimSpace(commit.Message), "\n", 2)[0]
+}
+
+// newOriginFileHistoryCommit returns a commit of the history of a file, the author is mapped by
+// the optional mailmap
+func newOriginFileHistoryCommit(commit *object.Commit, mailmap *mailmap) OriginFileHistoryCommit {
+	return OriginFileHistoryCommit{
+		Hash:    commit.Hash.String(),
+		Subject: getCommitSubject(commit),
+		Author:  mailmap.getAuthor(commit),
+		Date:    commit.Author.When,
+	}
+}
+
+// newOriginFileCommit returns the commit info of a Git commit, the author is mapped by the
+// optional mailmap
+func newOriginFileCommit(commit *object.Commit, mailmap *mailmap) *OriginFileCommit {
+	return &OriginFileCommit{
+		Author:  mailmap.getAuthor(commit),
+		Date:    commit.Author.When,
+		Hash:    commit.Hash.String(),
+		Subject: getCommitSubject(commit),
+	}
+}
+
+// addCommitInfo adds the commit info to the content files of the origin. The history of the
+// origin repository and of each submodule is walked only once for all of their files.
+func (origin *Origin) addCommitInfo() error {
+
+	// Origins without repository, like archives, have no commit info
+	if origin.config.DisableCommitInfo || origin.repo == nil {
+		return nil
+	}
+
+	repoFiles := map[*git.Repository]map[string][]*OriginFile{}
+	var repos []*git.Repository
+
+	for i := range origin.Files {
+		file := &origin.Files[i]
+
+		// Only get commit info for content files
+		// This speeds up commit fetching on repository with lots of files
+		// heavily. Most non content files are static and therefore way back
+		// in the commit log.
+		if !files.IsContentFile(file.RemotePath) {
+			continue
+		}
+
+		repo, repoPath := origin.repo, path.Join(origin.repoDir, file.RemotePath)
+		// Files of submodules are part of the history of the submodule
+		if submodule := origin.getSubmodule(file.RemotePath); submodule != nil {
+			file.submodule = submodule
+			repo, repoPath = submodule.repo, strings.TrimPrefix(file.RemotePath, submodule.Path+"/")
+		}
+
+		if repoFiles[repo] == nil {
+			repoFiles[repo] = map[string][]*OriginFile{}
+			repos = append(repos, repo)
+		}
+		repoFiles[repo][repoPath] = append(repoFiles[repo][repoPath], file)
+	}
+
+	for _, repo := range repos {
+
+		var paths []string
+		for p := range repoFiles[repo] {
+			paths = append(paths, p)
+		}
+
+		index, err := newCommitIndex(repo, paths, commitIndexOptions{
+			contributors:  origin.config.Contributors,
+			historyLength: origin.config.HistoryLength,
+		})
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error indexing commits of %s", origin.URL))
+		}
+
+		for p, files := range repoFiles[repo] {
+			commit := index.last[p]
+			if commit == nil {
+				origin.getLogger().Warnf("Can't extract Commit Info for '%s', file not found in git log", p)
+			}
+			for _, file := range files {
+				file.Commit = commit
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package compose
+
+// run: go test ./pkg/compose -run TestCommitIndex -bench BenchmarkCommitIndex
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+func TestCommitIndex(t *testing.T) {
+
+	repoDir := createTestRepository(t, map[string]string{
+		"README.md":         "# First",
+		"docs/old.md":       "# Old",
+		"docs/unchanged.md": "# Unchanged",
+	})
+	commitTestFiles(t, repoDir, map[string]string{"README.md": "# Second"})
+	lastDocs := commitTestFiles(t, repoDir, map[string]string{"docs/new.md": "# New"})
+
+	repo, err := git.PlainOpen(repoDir)
+	assert.NoError(t, err)
+
+	paths := []string{"README.md", "docs/old.md", "docs/unchanged.md", "docs/new.md", "docs/missing.md"}
+	index, err := newCommitIndex(repo, paths, commitIndexOptions{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, lastDocs.String(), index.last["docs/new.md"].Hash)
+	assert.Nil(t, index.last["docs/missing.md"])
+
+	for _, p := range paths[:4] {
+		commit, err := getCommitInfo(p, repo)
+		assert.NoError(t, err)
+		assert.Equal(t, commit, index.last[p], "Same commit as git log for %s", p)
+	}
+
+	t.Run("Merge commits", func(t *testing.T) {
+		worktree, err := repo.Worktree()
+		assert.NoError(t, err)
+		head, err := repo.Head()
+		assert.NoError(t, err)
+
+		// Change a file on a branch and merge it without changes
+		branchCommit := commitTestFiles(t, repoDir, map[string]string{"docs/old.md": "# Changed on branch"})
+		mergeCommit, err := worktree.Commit("Merge branch", &git.CommitOptions{
+			Author:  &object.Signature{Name: "Monako Test", Email: "monako@example.com", When: time.Now().Add(time.Second)},
+			Parents: []plumbing.Hash{branchCommit, head.Hash()},
+		})
+		assert.NoError(t, err)
+
+		index, err := newCommitIndex(repo, paths, commitIndexOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, branchCommit.String(), index.last["docs/old.md"].Hash, "Merges identical to a parent don't change files")
+		assert.Equal(t, lastDocs.String(), index.last["docs/new.md"].Hash)
+		assert.NotEqual(t, mergeCommit.String(), index.last["docs/old.md"].Hash)
+	})
+}
+
+func TestCommitIndexContributors(t *testing.T) {
+
+	repoDir := createTestRepository(t, map[string]string{"README.md": "# First"})
+	commitTestFilesAs(t, repoDir, object.Signature{Name: "Jane Doe", Email: "jane@example.com"}, map[string]string{"README.md": "# Second"})
+	commitTestFilesAs(t, repoDir, object.Signature{Name: "Jane D.", Email: "JANE@example.com"}, map[string]string{"README.md": "# Third"})
+
+	repo, err := git.PlainOpen(repoDir)
+	assert.NoError(t, err)
+
+	index, err := newCommitIndex(repo, []string{"README.md"}, commitIndexOptions{contributors: true})
+	assert.NoError(t, err)
+
+	commit := index.last["README.md"]
+	assert.Equal(t, "Jane D.", commit.Author.Name)
+	assert.False(t, commit.FirstDate.IsZero())
+	assert.False(t, commit.FirstDate.After(commit.Date), "First commit is not newer than the last commit")
+	assert.Equal(t, []OriginFileContributor{
+		{Name: "Jane D.", Email: "JANE@example.com", Commits: 2},
+		{Name: "Monako Test", Email: "monako@example.com", Commits: 1},
+	}, commit.Contributors, "Contributors are deduplicated by email with the name of their last commit")
+
+	t.Run("Without history", func(t *testing.T) {
+		index, err := newCommitIndex(repo, []string{"README.md"}, commitIndexOptions{})
+		assert.NoError(t, err)
+		assert.True(t, index.last["README.md"].FirstDate.IsZero())
+		assert.Empty(t, index.last["README.md"].Contributors)
+	})
+}
+
+func TestCommitIndexHistory(t *testing.T) {
+
+	repoDir := createTestRepository(t, map[string]string{"README.md": "# First", "docs/other.md": "# Other"})
+	second := commitTestFiles(t, repoDir, map[string]string{"README.md": "# Second"})
+	third := commitTestFiles(t, repoDir, map[string]string{"README.md": "# Third"})
+
+	repo, err := git.PlainOpen(repoDir)
+	assert.NoError(t, err)
+
+	for _, contributors := range []bool{false, true} {
+		t.Run(fmt.Sprintf("Contributors %t", contributors), func(t *testing.T) {
+			index, err := newCommitIndex(repo, []string{"README.md", "docs/other.md"}, commitIndexOptions{
+				contributors:  contributors,
+				historyLength: 2,
+			})
+			assert.NoError(t, err)
+
+			history := index.last["README.md"].History
+			assert.Len(t, history, 2, "Only the latest commits")
+			assert.Equal(t, third.String(), history[0].Hash)
+			assert.Equal(t, second.String(), history[1].Hash)
+			assert.Equal(t, "Add test files", history[0].Subject)
+			assert.Equal(t, "Monako Test", history[0].Author.Name)
+
+			assert.Len(t, index.last["docs/other.md"].History, 1, "Files with less commits")
+		})
+	}
+}
+
+func TestAddCommitInfo(t *testing.T) {
+
+	repoDir := createTestRepository(t, map[string]string{
+		"docs/README.md": "# Readme",
+		"docs/image.png": "PNG",
+	})
+
+	origin := NewOrigin(repoDir, "master", "docs", "docs/index")
+	config, _ := getTestConfig(t, *origin)
+
+	err := config.Compose()
+	assert.NoError(t, err)
+
+	for _, file := range config.Origins[0].Files {
+		if file.RemotePath == "docs/README.md" {
+			assert.NotNil(t, file.Commit)
+			assert.Equal(t, "Monako Test", file.Commit.Author.Name)
+			assert.Empty(t, file.Commit.Contributors, "Contributors are opt-in")
+		} else {
+			assert.Nil(t, file.Commit, "No commit info for non content files")
+		}
+	}
+}
+
+// BenchmarkCommitIndex compares the single history walk with a git log per file
+func BenchmarkCommitIndex(b *testing.B) {
+
+	repoDir := createTestRepositoryAt(b, filepath.Join(GetLocalTempDir(b), "repo"), map[string]string{"file0.md": "# 0"})
+	var paths []string
+	for i := 1; i < 100; i++ {
+		p := fmt.Sprintf("file%d.md", i)
+		paths = append(paths, p)
+		commitTestFiles(b, repoDir, map[string]string{p: p})
+	}
+
+	repo, err := git.PlainOpen(repoDir)
+	assert.NoError(b, err)
+
+	b.Run("Git log per file", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			for _, p := range paths {
+				_, err := getCommitInfo(p, repo)
+				assert.NoError(b, err)
+			}
+		}
+	})
+
+	b.Run("Commit index", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			_, err := newCommitIndex(repo, paths, commitIndexOptions{})
+			assert.NoError(b, err)
+		}
+	})
+
+	b.Run("Commit index with contributors", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			_, err := newCommitIndex(repo, paths, commitIndexOptions{contributors: true})
+			assert.NoError(b, err)
+		}
+	})
+}
@@ -1,10 +1,14 @@
 package compose
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -23,13 +27,72 @@ type Config struct {
 
 	DisableCommitInfo bool `yaml:"disableCommitInfo"`
 
+	// Contributors adds the contributors and the first commit date to the documents, which walks
+	// the whole history instead of searching only the last commit of each document
+	Contributors bool `yaml:"contributors"`
+
+	// DisableRecentChanges skips the page and feed of the documents changed last
+	DisableRecentChanges bool `yaml:"disableRecentChanges"`
+
+	// HistoryLength is the number of latest commits of each document added to its frontmatter
+	HistoryLength int `yaml:"historyLength"`
+
+	// EmailPrivacy controls how author emails are published: show, hide or hash
+	EmailPrivacy string `yaml:"emailPrivacy"`
+
+	// EmailAliases replaces the emails of authors with public aliases
+	EmailAliases map[string]string `yaml:"emailAliases"`
+
+	// DisableLinkCheck skips checking the links of the rendered pages
+	DisableLinkCheck bool `yaml:"disableLinkCheck"`
+
+	// CheckExternalLinks are glob patterns of hosts like "*.example.com" whose links are requested
+	// when checking the links of the rendered pages
+	CheckExternalLinks []string `yaml:"checkExternalLinks"`
+
+	// GenerateMenu generates the menu from the composed documents, the menu config becomes an
+	// optional header of the generated menu
+	GenerateMenu bool `yaml:"generateMenu"`
+
+	// DisableSectionIndex skips generating section index pages for composed directories without one
+	DisableSectionIndex bool `yaml:"disableSectionIndex"`
+
+	// ListSectionPages lists the pages and sections of a directory on its generated section index
+	ListSectionPages bool `yaml:"listSectionPages"`
+
+	// Menu is the structure of the menu, it replaces the menu config
+	Menu []MenuEntry `yaml:"menu"`
+
+	// CacheDir is the directory where cloned repositories are kept between runs.
+	// If empty, repositories are cloned into memory.
+	CacheDir string `yaml:"cacheDir"`
+
+	// Concurrency is the number of origins that are cloned and composed in parallel
+	Concurrency int `yaml:"concurrency"`
+
 	// HugoWorkingDir is the working dir for the Composition. For example "your/dir/compose"
 	HugoWorkingDir string
 
 	// ContentWorkingDir is the main working dir and where all the content is stored in. For example "your/dir/"
 	ContentWorkingDir string
+
+	// menuHeader is the handwritten part of a generated menu
+	menuHeader string
+	// menuConfigFile is the path of the menu config, used for reporting broken references
+	menuConfigFile string
+	// menuSources describe the origin of the lines of the composed menu that are not taken from
+	// the menu config, used for reporting broken references
+	menuSources []string
+	// failOnError fails composing on broken references of the menu instead of warning about them
+	failOnError bool
+
+	// cacheLocks holds a mutex per cache dir, so origins sharing a cached repository don't run in parallel
+	cacheLocks sync.Map
 }
 
+// maxInMemoryConcurrency is the maximum number of origins composed in parallel without a cache dir
+const maxInMemoryConcurrency = 4
+
 // CommandLineSettings contains all the flags and settings made via the command line in main
 type CommandLineSettings struct {
 	// ConfigFilePath is the path to the Monako config
@@ -44,12 +107,16 @@ type CommandLineSettings struct {
 	Trace bool
 	// ShowVersion shows the current version and exists
 	ShowVersion bool
-	// FailOnHugoError will fail Monako when there are Hugo errors during build
+	// FailOnHugoError will fail Monako when there are Hugo errors or broken links during build
 	FailOnHugoError bool
 	// OnlyCompose will only compose files but not generate HTML
 	OnlyCompose bool
 	// OnlyRender will only render HTML files but not compose them
 	OnlyRender bool
+	// CacheDir is the directory for cached repositories, overwrites the config
+	CacheDir string
+	// Concurrency is the number of origins composed in parallel, overwrites the config
+	Concurrency int
 }
 
 // LoadConfig returns the Monako config from the given configfilepath
@@ -90,6 +157,23 @@ func (config *Config) initConfig(workingdir string) {
 // Compose builds the Monako directory structure
 func (config *Config) Compose() error {
 
+	err := config.checkEmailPrivacy()
+	if err != nil {
+		return err
+	}
+
+	err = config.expandVersions()
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error expanding versioned origins"))
+	}
+
+	for i := range config.Origins {
+		err = config.Origins[i].checkForge()
+		if err != nil {
+			return err
+		}
+	}
+
 	// If Origin has now own whitelist, use the Compose Whitelist
 	for i := range config.Origins {
 		if config.Origins[i].FileWhitelist == nil {
@@ -98,42 +182,158 @@ func (config *Config) Compose() error {
 		if config.Origins[i].FileBlacklist == nil {
 			config.Origins[i].FileBlacklist = config.FileBlacklist
 		}
+	}
 
-		filesystem, err := config.Origins[i].CloneDir()
-		if err != nil {
-			return errors.Wrap(err, fmt.Sprintf("Error cloning origin %s", config.Origins[i].URL))
+	workers := config.getConcurrency()
+	if workers > 1 {
+		fmt.Printf("Composing %d origins with %d workers\n", len(config.Origins), workers)
+	}
+
+	// Every origin reports back on its own channel, this way the
+	// output can be printed in the order of the config
+	done := make([]chan error, len(config.Origins))
+	outputs := make([]*bytes.Buffer, len(config.Origins))
+	for i := range config.Origins {
+		done[i] = make(chan error, 1)
+		if workers > 1 {
+			outputs[i] = new(bytes.Buffer)
+			config.Origins[i].setOutput(outputs[i])
 		}
+	}
 
-		err = config.Origins[i].ComposeDir(filesystem)
-		if err != nil {
-			return errors.Wrap(err, fmt.Sprintf("Error composing dir '%s' of %s", config.Origins[i].SourceDir, config.Origins[i].URL))
+	var failed int32
+	jobs := make(chan int)
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range jobs {
+				if atomic.LoadInt32(&failed) != 0 {
+					// Skip remaining origins after the first error
+					done[i] <- nil
+					continue
+				}
+				err := config.composeOrigin(i)
+				if err != nil {
+					atomic.StoreInt32(&failed, 1)
+				}
+				done[i] <- err
+			}
+		}()
+	}
+
+	go func() {
+		for i := range config.Origins {
+			jobs <- i
 		}
+		close(jobs)
+	}()
 
-		// After processing the origin, delete repo for freeing up memory
-		// containing the whole virtual filesystem. Can easily add up to
-		// multiple gigabyte
-		config.Origins[i].repo = nil
+	var firstErr error
+	for i := range config.Origins {
+		err := <-done[i]
+		if outputs[i] != nil {
+			_, _ = io.Copy(os.Stdout, outputs[i])
+			config.Origins[i].out = nil
+			config.Origins[i].logger = nil
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return firstErr
+	}
 
-		// Performance analysis ------
+	err = config.linkVersions()
+	if err != nil {
+		return err
+	}
 
-		// Frees up some more megabyte
-		// debug.FreeOSMemory()
+	err = config.writeSectionIndexes()
+	if err != nil {
+		return err
+	}
 
-		// if os.Getenv("MONAKO_LOG_HEAP") == "true" {
+	err = config.writeRecentChanges()
+	if err != nil {
+		return err
+	}
 
-		// 	f, err := os.Create(filepath.Join(fmt.Sprintf("origin_%d.heap.fix.log", i)))
-		// 	if err != nil {
-		// 		log.Fatal(err)
-		// 	}
-		// 	pprof.WriteHeapProfile(f)
-		// 	f.Close()
-		// }
+	err = config.writeComposedMenu()
+	if err != nil {
+		return err
+	}
 
-		// End Performance analysis ------
+	// Broken references of the menu break the rendering, report them before Hugo runs
+	return config.checkMenuRefs()
 
+}
+
+// composeOrigin clones and composes a single origin of the config
+func (config *Config) composeOrigin(i int) error {
+
+	origin := &config.Origins[i]
+
+	if config.CacheDir != "" && origin.isGit() {
+		// Origins with the same repository and branch share their cache dir
+		lock, _ := config.cacheLocks.LoadOrStore(origin.getCacheDir(), new(sync.Mutex))
+		lock.(*sync.Mutex).Lock()
+		defer lock.(*sync.Mutex).Unlock()
+	}
+
+	filesystem, err := origin.openFilesystem()
+	if err != nil {
+		return err
 	}
+
+	err = origin.ComposeDir(filesystem)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error composing dir '%s' of %s", origin.SourceDir, origin.URL))
+	}
+
+	// After processing the origin, delete repo for freeing up memory
+	// containing the whole virtual filesystem. Can easily add up to
+	// multiple gigabyte
+	origin.repo = nil
+
+	// Performance analysis ------
+
+	// Frees up some more megabyte
+	// debug.FreeOSMemory()
+
+	// if os.Getenv("MONAKO_LOG_HEAP") == "true" {
+
+	// 	f, err := os.Create(filepath.Join(fmt.Sprintf("origin_%d.heap.fix.log", i)))
+	// 	if err != nil {
+	// 		log.Fatal(err)
+	// 	}
+	// 	pprof.WriteHeapProfile(f)
+	// 	f.Close()
+	// }
+
+	// End Performance analysis ------
+
 	return nil
+}
+
+// getConcurrency returns the number of origins that are composed in parallel.
+// Origins cloned into memory can hold multiple gigabyte each, so their number
+// is limited unless a cache dir is used.
+func (config *Config) getConcurrency() int {
 
+	workers := config.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+
+	if config.CacheDir == "" && workers > maxInMemoryConcurrency {
+		log.Warnf("Limiting concurrency to %d since origins are cloned into memory, set a cache dir for higher values", maxInMemoryConcurrency)
+		workers = maxInMemoryConcurrency
+	}
+
+	if workers > len(config.Origins) && len(config.Origins) > 0 {
+		workers = len(config.Origins)
+	}
+	return workers
 }
 
 // CleanUp removes the compose folder
@@ -170,6 +370,18 @@ func Init(cliSettings CommandLineSettings) (config *Config) {
 		config.BaseURL = cliSettings.BaseURL
 	}
 
+	if cliSettings.CacheDir != "" {
+		// Overwrite config cache dir if it is set as parameter
+		config.CacheDir = cliSettings.CacheDir
+	}
+
+	if cliSettings.Concurrency > 0 {
+		// Overwrite config concurrency if it is set as parameter
+		config.Concurrency = cliSettings.Concurrency
+	}
+
+	config.failOnError = cliSettings.FailOnHugoError
+
 	if !cliSettings.OnlyRender {
 		// Dont do these steps if only generate
 		config.CleanUp()
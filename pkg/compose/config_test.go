@@ -83,6 +83,25 @@ func BenchmarkHugoRepositorySingleFiles(b *testing.B) {
 
 	})
 
+	b.Run("Commit Index for Hugo", func(b *testing.B) {
+
+		for n := 0; n < b.N; n++ {
+			index, err := newCommitIndex(origin.repo, []string{"README.md", "docs/archetypes/default.md"}, commitIndexOptions{})
+			assert.NoError(b, err)
+			assert.Len(b, index.last, 2)
+		}
+
+	})
+
+	b.Run("Commit Index with contributors for Hugo", func(b *testing.B) {
+
+		for n := 0; n < b.N; n++ {
+			_, err := newCommitIndex(origin.repo, []string{"README.md", "docs/archetypes/default.md"}, commitIndexOptions{contributors: true})
+			assert.NoError(b, err)
+		}
+
+	})
+
 }
 
 func BenchmarkSlowRepositorySingleFiles(b *testing.B) {
@@ -131,6 +150,25 @@ func BenchmarkSlowRepositorySingleFiles(b *testing.B) {
 
 	})
 
+	b.Run("Commit Index", func(b *testing.B) {
+
+		for n := 0; n < b.N; n++ {
+			index, err := newCommitIndex(origin.repo, []string{slowRepoFile1, slowRepoFile2}, commitIndexOptions{})
+			assert.NoError(b, err)
+			assert.Len(b, index.last, 2)
+		}
+
+	})
+
+	b.Run("Commit Index with contributors", func(b *testing.B) {
+
+		for n := 0; n < b.N; n++ {
+			_, err := newCommitIndex(origin.repo, []string{slowRepoFile1, slowRepoFile2}, commitIndexOptions{contributors: true})
+			assert.NoError(b, err)
+		}
+
+	})
+
 }
 
 func BenchmarkWholeRepoHugoRepositoryWholeRepo(b *testing.B) {
@@ -236,7 +274,7 @@ func getLocalOrRemoteRepo(t *testing.T) *Origin {
 	return NewOrigin(testRepo, "master", ".", "docs/monako-test")
 
 }
-func GetLocalTempDir(t *testing.T) (tempdir string) {
+func GetLocalTempDir(t testing.TB) (tempdir string) {
 
 	localTmpDir := filepath.Join("../../tmp/testdata/", t.Name())
 	err := os.MkdirAll(localTmpDir, standardFilemode)
@@ -298,3 +336,48 @@ func TestInit(t *testing.T) {
 	})
 
 }
+
+func TestComposeConcurrently(t *testing.T) {
+
+	var origins []Origin
+	for _, name := range []string{"first", "second", "third"} {
+		repoDir := createTestRepository(t, map[string]string{
+			name + ".md": "# " + name,
+		})
+		origins = append(origins, *NewOrigin(repoDir, "master", ".", "docs/"+name))
+	}
+
+	config, _ := getTestConfig(t, origins...)
+	config.Concurrency = 3
+
+	err := config.Compose()
+	assert.NoError(t, err)
+
+	for _, name := range []string{"first", "second", "third"} {
+		assert.FileExists(t, filepath.Join(config.ContentWorkingDir, "docs", name, name+".md"))
+	}
+
+	t.Run("Errors are wrapped like sequential composing", func(t *testing.T) {
+		config, _ := getTestConfig(t, origins[0], *NewOrigin("/does/not/exist", "master", ".", "docs/missing"))
+		config.Concurrency = 2
+
+		err := config.Compose()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "Error cloning origin /does/not/exist")
+	})
+}
+
+func TestGetConcurrency(t *testing.T) {
+
+	config := &Config{Origins: make([]Origin, 10)}
+	assert.Equal(t, 1, config.getConcurrency(), "Sequential by default")
+
+	config.Concurrency = 8
+	assert.Equal(t, maxInMemoryConcurrency, config.getConcurrency(), "In memory clones are limited")
+
+	config.CacheDir = "cache"
+	assert.Equal(t, 8, config.getConcurrency(), "Cached clones are not limited")
+
+	config.Concurrency = 20
+	assert.Equal(t, 10, config.getConcurrency(), "No more workers than origins")
+}
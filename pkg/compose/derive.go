@@ -0,0 +1,159 @@
+package compose
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// maxDescriptionLength is the maximum length of a description derived from the first paragraph
+const maxDescriptionLength = 300
+
+// markdownTitlePattern matches a Markdown H1 like "# Title #"
+var markdownTitlePattern = regexp.MustCompile(`^ {0,3}#[ \t]+(.+?)(?:[ \t]+#+)?[ \t]*$`)
+
+// markdownSetextTitlePattern matches the underline of a Markdown H1 like "Title\n====="
+var markdownSetextTitlePattern = regexp.MustCompile(`^ {0,3}=+[ \t]*$`)
+
+// asciidocTitlePattern matches the AsciiDoc document title like "= Title"
+var asciidocTitlePattern = regexp.MustCompile(`^=[ \t]+(.+?)[ \t]*$`)
+
+// nonParagraphPattern matches lines that can't start a paragraph like headings, lists, tables,
+// quotes, HTML, images, badges, AsciiDoc attributes, block attributes, macros and comments
+var nonParagraphPattern = regexp.MustCompile(`^\s*(?:#|=|[-*+] |\d+[.)] |\||>|<|!\[|\[!\[|:[\w-]+:|\[.*\]\s*$|[\w-]+::|//|'''|\*\*\*|---)`)
+
+// inlineLinkPattern matches Markdown links and images and AsciiDoc link macros, keeping their text
+var inlineLinkPattern = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)|\b(?:link|xref|https?):[^\s\[]*\[([^\]]*)\]`)
+
+// getDerivedParams returns the title of the first heading and the description of the first
+// paragraph of the document, if the origin derives them and the frontmatter has none
+func (file *OriginFile) getDerivedParams(content string, params yaml.MapSlice) (yaml.MapSlice, error) {
+
+	origin := file.parentOrigin
+	deriveTitle := !origin.DisableDerivedTitle && !hasParam(params, "title")
+	deriveDescription := !origin.DisableDerivedDescription && !hasParam(params, "description")
+	if !deriveTitle && !deriveDescription {
+		return nil, nil
+	}
+
+	_, body, err := splitFrontmatterAndBody(content)
+	if err != nil {
+		return nil, err
+	}
+
+	title, description := getTitleAndDescription(body, file.GetFormat())
+
+	var derived yaml.MapSlice
+	if deriveTitle && title != "" {
+		derived = append(derived, yaml.MapItem{Key: "title", Value: title})
+	}
+	if deriveDescription && description != "" {
+		derived = append(derived, yaml.MapItem{Key: "description", Value: description})
+	}
+	return derived, nil
+}
+
+// getTitleAndDescription returns the text of the first level one heading and of the first paragraph
+// of the body of a document. Code blocks are skipped.
+func getTitleAndDescription(body string, format string) (title string, description string) {
+
+	lines := strings.Split(body, "\n")
+	inCodeBlock := false
+	// The AsciiDoc header with author and attribute lines ends with the first empty line
+	inHeader := false
+	var paragraph []string
+
+	for i, line := range lines {
+
+		if isCodeBlockDelimiter(line, format) {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			continue
+		}
+
+		if title == "" && paragraph == nil {
+			if heading := getTitle(lines, i, format); heading != "" {
+				title = heading
+				inHeader = format == Asciidoc
+				continue
+			}
+		}
+
+		if inHeader {
+			inHeader = strings.TrimSpace(line) != ""
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			if paragraph != nil {
+				break
+			}
+			continue
+		}
+
+		// A setext underline belongs to the title
+		if format == Markdown && markdownSetextTitlePattern.MatchString(line) && paragraph == nil {
+			continue
+		}
+
+		if paragraph == nil && nonParagraphPattern.MatchString(line) {
+			continue
+		}
+		paragraph = append(paragraph, strings.TrimSpace(line))
+	}
+
+	return title, shortenText(getPlainText(strings.Join(paragraph, " ")), maxDescriptionLength)
+}
+
+// getTitle returns the title if the line at index i is a level one heading
+func getTitle(lines []string, i int, format string) string {
+	line := lines[i]
+	switch format {
+	case Markdown:
+		if match := markdownTitlePattern.FindStringSubmatch(line); match != nil {
+			return getPlainText(match[1])
+		}
+		if strings.TrimSpace(line) != "" && i+1 < len(lines) && markdownSetextTitlePattern.MatchString(lines[i+1]) {
+			return getPlainText(strings.TrimSpace(line))
+		}
+	case Asciidoc:
+		if match := asciidocTitlePattern.FindStringSubmatch(line); match != nil {
+			return getPlainText(match[1])
+		}
+	}
+	return ""
+}
+
+// getPlainText removes links and emphasis markup of a line
+func getPlainText(text string) string {
+	text = inlineLinkPattern.ReplaceAllString(text, "$1$2")
+	text = strings.NewReplacer("**", "", "__", "", "`", "").Replace(text)
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// shortenText shortens the text to the maximum length at a word boundary
+func shortenText(text string, maxLength int) string {
+	runes := []rune(text)
+	if len(runes) <= maxLength {
+		return text
+	}
+	shortened := string(runes[:maxLength])
+	if i := strings.LastIndex(shortened, " "); i > 0 {
+		shortened = shortened[:i]
+	}
+	return strings.TrimRight(shortened, " ,;:.") + "…"
+}
+
+// hasParam returns true if the params contain the key
+func hasParam(params yaml.MapSlice, key string) bool {
+	for _, param := range params {
+		if strings.EqualFold(fmt.Sprint(param.Key), key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package compose
+
+// run: go test ./pkg/compose -run TestGetTitleAndDescription
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTitleAndDescription(t *testing.T) {
+
+	cases := []struct {
+		name, format, body, title, description string
+	}{
+		{"Markdown", Markdown,
+			"[![Build](https://ci/badge.svg)](https://ci)\n\n# The **Monako** Guide #\n\n## Install\n\nMonako composes [documentation](docs/README.md)\nfrom `Git` repositories.\n\nSecond paragraph.",
+			"The Monako Guide", "Monako composes documentation from Git repositories."},
+		{"Setext heading", Markdown, "Monako\n======\n\nComposes documentation.", "Monako", "Composes documentation."},
+		{"Code blocks are skipped", Markdown, "```\n# Not a title\n```\n\n# Title\n\n```\ncode\n```\n\n- List item\n\nText", "Title", "Text"},
+		{"Without heading", Markdown, "Just text\non two lines", "", "Just text on two lines"},
+		{"Second level heading only", Markdown, "## Section\n\nText", "", "Text"},
+		{"AsciiDoc", Asciidoc,
+			"= Monako Manual\nJane Doe <jane@example.com>\n:toc:\n\n[NOTE]\nComposes link:https://example.com[documentation].\n\n== Usage\n",
+			"Monako Manual", "Composes documentation."},
+		{"AsciiDoc listing", Asciidoc, "----\n= Not a title\n----\n\n== Section\n\nText", "", "Text"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			title, description := getTitleAndDescription(tc.body, tc.format)
+			assert.Equal(t, tc.title, title)
+			assert.Equal(t, tc.description, description)
+		})
+	}
+
+	t.Run("Long descriptions are shortened", func(t *testing.T) {
+		_, description := getTitleAndDescription(strings.Repeat("word ", 100), Markdown)
+		assert.True(t, strings.HasSuffix(description, "word…"))
+		assert.LessOrEqual(t, len([]rune(description)), maxDescriptionLength+1)
+	})
+}
+
+func TestDerivedFrontmatter(t *testing.T) {
+
+	newFile := func(origin *Origin) *OriginFile {
+		return &OriginFile{LocalPath: "localpath", RemotePath: "docs/README.md", parentOrigin: origin}
+	}
+
+	result, err := newFile(&Origin{}).ExpandFrontmatter("# Monako\n\nComposes documentation.")
+	assert.NoError(t, err)
+	assert.Equal(t, "---\n\ntitle: Monako\ndescription: Composes documentation.\n---\n\n# Monako\n\nComposes documentation.", result)
+
+	t.Run("Existing title and description are kept", func(t *testing.T) {
+		result, err := newFile(&Origin{}).ExpandFrontmatter("---\nTitle: Existing\ndescription: Existing\n---\n# Monako\n\nComposes documentation.")
+		assert.NoError(t, err)
+		assert.NotContains(t, result, "Monako\n---")
+		assert.NotContains(t, result, "description: Composes")
+	})
+
+	t.Run("Switches", func(t *testing.T) {
+		result, err := newFile(&Origin{DisableDerivedTitle: true}).ExpandFrontmatter("# Monako\n\nComposes documentation.")
+		assert.NoError(t, err)
+		assert.NotContains(t, result, "title:")
+		assert.Contains(t, result, "description: Composes documentation.")
+
+		result, err = newFile(&Origin{DisableDerivedTitle: true, DisableDerivedDescription: true}).ExpandFrontmatter("# Monako")
+		assert.NoError(t, err)
+		assert.Equal(t, "# Monako", result)
+	})
+}
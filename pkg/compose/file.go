@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"net/url"
 	"os"
 	"path"
 	"path/filepath"
@@ -37,13 +36,38 @@ type OriginFile struct {
 
 	// parentOrigin of this file
 	parentOrigin *Origin
+	// submodule containing this file, nil if the file is part of the origin repository
+	submodule *originSubmodule
 }
 
 // OriginFileCommit represents a commit
 type OriginFileCommit struct {
-	Hash   string
-	Author OriginFileCommitter
-	Date   time.Time
+	Hash    string
+	Subject string
+	Author  OriginFileCommitter
+	Date    time.Time
+
+	// FirstDate is the date of the first commit of the file
+	FirstDate time.Time
+	// Contributors are the deduplicated authors of all commits of the file, most commits first
+	Contributors []OriginFileContributor
+	// History are the latest commits of the file, newest first
+	History []OriginFileHistoryCommit
+}
+
+// OriginFileHistoryCommit represents a commit in the history of a file
+type OriginFileHistoryCommit struct {
+	Hash    string
+	Subject string
+	Author  OriginFileCommitter
+	Date    time.Time
+}
+
+// OriginFileContributor represents an author of commits of a file
+type OriginFileContributor struct {
+	Name    string `yaml:"name"`
+	Email   string `yaml:"email,omitempty"`
+	Commits int    `yaml:"commits"`
 }
 
 // OriginFileCommitter represents the committer of a commit
@@ -72,7 +96,7 @@ func (file *OriginFile) composeFile(filesystem billy.Filesystem) error {
 			return errors.Wrap(err, fmt.Sprintf("Error copying regular file"))
 		}
 	}
-	fmt.Printf("%s -> %s\n", file.RemotePath, file.LocalPath)
+	file.parentOrigin.printf("%s -> %s\n", file.RemotePath, file.LocalPath)
 	return nil
 
 }
@@ -118,7 +142,8 @@ func (file *OriginFile) copyRegularFile(filesystem billy.Filesystem) error {
 }
 
 // getCommitInfo returns the Commit Info for a given file of the repository
-// identified by it's filename
+// identified by it's filename. It walks the history for every call, composing
+// uses a commitIndex instead. It is kept as the baseline of the benchmarks.
 func getCommitInfo(remotePath string, repo *git.Repository) (*OriginFileCommit, error) {
 
 	log.Debugf("Getting commit info for %s", remotePath)
@@ -148,14 +173,7 @@ func getCommitInfo(remotePath string, repo *git.Repository) (*OriginFileCommit,
 	// This has to be here, otherwise the iterator will return garbage
 	defer cIter.Close()
 
-	return &OriginFileCommit{
-		Author: OriginFileCommitter{
-			Name:  returnCommit.Author.Name,
-			Email: returnCommit.Author.Email,
-		},
-		Date: returnCommit.Author.When,
-		Hash: returnCommit.Hash.String(),
-	}, nil
+	return newOriginFileCommit(returnCommit, nil), nil
 }
 
 func (file *OriginFile) copyMarkupFile(filesystem billy.Filesystem) error {
@@ -169,9 +187,9 @@ func (file *OriginFile) copyMarkupFile(filesystem billy.Filesystem) error {
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("Error reading markup file %s", file.RemotePath))
 	}
-	content := string(c)
+	content := file.rewriteLinks(string(c), filesystem)
 
-	content, err = file.ExpandFrontmatter(string(content))
+	content, err = file.ExpandFrontmatter(content)
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("Error expanding frontmatter for %s -> %s", file.RemotePath, file.LocalPath))
 	}
@@ -195,120 +213,314 @@ func getLocalFilePath(composeDir, remoteDocDir string, targetDir string, remoteF
 // ExpandFrontmatter expands the existing frontmatter with the parameters given
 func (file *OriginFile) ExpandFrontmatter(content string) (expandedFrontmatter string, err error) {
 
-	if file.Commit == nil {
-		log.Debug("Git Info is not set, returning without adding it")
+	params := file.getFrontmatterParams()
+
+	if file.getSectionTitle() != "" {
+		// The title of the origin wins over the title of its section index page
+		content, err = removeFrontmatterParams(content, "title")
+		if err != nil {
+			return "", errors.Wrap(err, fmt.Sprintf("Error replacing title"))
+		}
+	}
+
+	derived, err := file.getDerivedParams(content, params)
+	if err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("Error deriving title and description"))
+	}
+	params = append(params, derived...)
+
+	if len(params) == 0 {
+		file.parentOrigin.getLogger().Debug("No frontmatter parameters to add, returning without adding them")
 		return content, nil
 	}
 
-	oldFrontmatter, body, err := splitFrontmatterAndBody(content)
+	expandedFrontmatter, err = addFrontmatterParams(content, params)
 	if err != nil {
 		return "", errors.Wrap(err, fmt.Sprintf("Error expanding front matter"))
 	}
+	return expandedFrontmatter, nil
+}
 
-	return fmt.Sprintf(`---
-%s
-
-MonakoGitRemote: %s
-MonakoGitRemotePath: %s
-MonakoGitURL: %s
-MonakoGitLastCommitHash: %s
-MonakoGitURLCommit: %s
-lastMod: %s
-MonakoGitLastCommitAuthor: %s
-MonakoGitLastCommitAuthorEmail: %s
----
-
-%s`,
-			oldFrontmatter,
-			file.parentOrigin.URL,
-			file.RemotePath,
-			getWebLinkForFileInGit(
-				file.parentOrigin.URL,
-				file.parentOrigin.Branch,
-				file.RemotePath,
-			),
-			file.Commit.Hash,
-			getWebLinkForGitCommit(
-				file.parentOrigin.URL,
-				file.Commit.Hash,
-			),
+// getFrontmatterParams returns the Monako parameters that are added to the frontmatter of this file
+func (file *OriginFile) getFrontmatterParams() yaml.MapSlice {
+
+	var params yaml.MapSlice
+
+	// Like edit links, the location of the file doesn't need the commit info
+	remote, remotePath, ref := file.getGitLocation()
+	refType := file.getWebRefType()
+	forge := file.parentOrigin.getForge(remote)
+	if remote != "" {
+		params = append(params, yaml.MapSlice{
+			{Key: "MonakoGitRemote", Value: remote},
+			{Key: "MonakoGitRemotePath", Value: remotePath},
+			{Key: "MonakoGitURL", Value: forge.getFileURL(ref, refType, remotePath)},
+			{Key: "MonakoGitURLHistory", Value: forge.getHistoryURL(ref, refType, remotePath)},
+			{Key: "MonakoGitRef", Value: ref},
+		}...)
+	}
+
+	if file.Commit != nil {
+		params = append(params, yaml.MapSlice{
+			{Key: "MonakoGitOriginCommit", Value: file.parentOrigin.resolvedCommit},
+			{Key: "MonakoGitLastCommitHash", Value: file.Commit.Hash},
+			{Key: "MonakoGitURLCommit", Value: forge.getCommitURL(file.Commit.Hash)},
 			// Use lastMod because other variables won't be parsed as date by Hugo
 			// Resulting in no date format functions on the file
-			file.Commit.Date.Format(time.RFC3339),
-			file.Commit.Author.Name,
-			file.Commit.Author.Email,
-			body,
-		),
-		nil
+			{Key: "lastMod", Value: file.Commit.Date.Format(time.RFC3339)},
+			{Key: "MonakoGitLastCommitAuthor", Value: file.Commit.Author.Name},
+		}...)
+
+		config := file.parentOrigin.config
+		if email := config.getPublicEmail(file.Commit.Author.Email); email != "" {
+			params = append(params, yaml.MapItem{Key: "MonakoGitLastCommitAuthorEmail", Value: email})
+		}
+
+		if len(file.Commit.Contributors) > 0 {
+			var names []string
+			var contributors []OriginFileContributor
+			for _, contributor := range file.Commit.Contributors {
+				names = append(names, contributor.Name)
+				contributor.Email = config.getPublicEmail(contributor.Email)
+				contributors = append(contributors, contributor)
+			}
+			params = append(params, yaml.MapSlice{
+				// Use date for the same reason as lastMod
+				{Key: "date", Value: file.Commit.FirstDate.Format(time.RFC3339)},
+				{Key: "MonakoGitFirstCommitDate", Value: file.Commit.FirstDate.Format(time.RFC3339)},
+				{Key: "MonakoGitContributors", Value: contributors},
+				// Taxonomy with a page per contributor listing their documents
+				{Key: contributorsTaxonomy, Value: names},
+			}...)
+		}
+
+		if len(file.Commit.History) > 0 {
+			params = append(params, yaml.MapItem{Key: "MonakoGitHistory", Value: file.getHistoryParams(forge)})
+		}
+	}
+
+	// Edit links don't need the commit info, only files on a branch can be edited
+	if !file.parentOrigin.DisableEditLinks && refType == refTypeBranch {
+		if editURL := forge.getEditURL(ref, refTypeBranch, remotePath); editURL != "" {
+			params = append(params, yaml.MapItem{Key: "MonakoGitURLEdit", Value: editURL})
+		}
+	}
+
+	if title := file.getSectionTitle(); title != "" {
+		params = append(params, yaml.MapItem{Key: "title", Value: title})
+	}
+
+	params = append(params, file.getVersionParams()...)
 
+	return params
 }
 
-func splitFrontmatterAndBody(content string) (frontmatter string, body string, err error) {
-	contentFrontmatter, err := pageparser.ParseFrontMatterAndContent(strings.NewReader(content))
+// getHistoryParams returns the latest commits of the file for a history in the frontmatter
+func (file *OriginFile) getHistoryParams(forge *forge) []yaml.MapSlice {
+
+	config := file.parentOrigin.config
+
+	var history []yaml.MapSlice
+	for _, commit := range file.Commit.History {
+		entry := yaml.MapSlice{
+			{Key: "hash", Value: commit.Hash},
+			{Key: "subject", Value: commit.Subject},
+			{Key: "author", Value: commit.Author.Name},
+		}
+		if email := config.getPublicEmail(commit.Author.Email); email != "" {
+			entry = append(entry, yaml.MapItem{Key: "email", Value: email})
+		}
+		entry = append(entry, yaml.MapSlice{
+			{Key: "date", Value: commit.Date.Format(time.RFC3339)},
+			{Key: "url", Value: forge.getCommitURL(commit.Hash)},
+		}...)
+		history = append(history, entry)
+	}
+	return history
+}
+
+// getGitLocation returns the remote URL of the repository containing the file, the path of the
+// file in this repository and the ref used for web links. Files of submodules link to the
+// submodule repository at the checked out commit.
+func (file *OriginFile) getGitLocation() (remote string, remotePath string, ref string) {
+	if file.submodule != nil {
+		return file.submodule.URL, strings.TrimPrefix(file.RemotePath, file.submodule.Path+"/"), file.submodule.Commit
+	}
+	return file.parentOrigin.URL, file.RemotePath, file.parentOrigin.getWebRef()
+}
+
+// getWebRefType returns the type of the ref returned by getGitLocation
+func (file *OriginFile) getWebRefType() string {
+	if file.submodule != nil {
+		return refTypeCommit
+	}
+	return file.parentOrigin.getWebRefType()
+}
+
+// addFrontmatterParams adds the params to the frontmatter of the content. Parameters that are
+// already set in the existing frontmatter are kept, since the document author knows best.
+func addFrontmatterParams(content string, params yaml.MapSlice) (string, error) {
+
+	oldFrontmatter, body, err := splitFrontmatterAndBody(content)
 	if err != nil {
-		return "", "", errors.Wrap(err, fmt.Sprintf("While splitting frontmatter for content: %s", content))
+		return "", err
 	}
 
-	// No frontmatter found, return old content
-	if contentFrontmatter.Content == nil {
-		return "", content, nil
+	existing := map[string]interface{}{}
+	err = yaml.Unmarshal([]byte(oldFrontmatter), &existing)
+	if err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("Error while reading existing frontmatter"))
 	}
 
-	contentMarshaled, err := yaml.Marshal(contentFrontmatter.FrontMatter)
+	var newParams yaml.MapSlice
+	for _, param := range params {
+		if !frontmatterHasKey(existing, fmt.Sprint(param.Key)) {
+			newParams = append(newParams, param)
+		}
+	}
+
+	newFrontmatter, err := yaml.Marshal(newParams)
 	if err != nil {
-		return "", "", errors.Wrap(err, fmt.Sprintf("Error while marshalling frontmatter to YAML"))
+		return "", errors.Wrap(err, fmt.Sprintf("Error while marshalling frontmatter to YAML"))
 	}
 
-	return string(contentMarshaled), string(contentFrontmatter.Content), nil
+	return fmt.Sprintf("---\n%s\n%s---\n\n%s", oldFrontmatter, newFrontmatter, body), nil
 }
 
-func getWebLinkForFileInGit(gitURL string, branch string, remotePath string) string {
+// removeFrontmatterParams removes the keys from the frontmatter of the content
+func removeFrontmatterParams(content string, keys ...string) (string, error) {
 
-	// URLs for checkout have .git suffix
-	gitURL = strings.TrimSuffix(gitURL, ".git")
-	u, err := url.Parse(gitURL)
+	oldFrontmatter, body, err := splitFrontmatterAndBody(content)
+	if err != nil {
+		return "", err
+	}
+	if oldFrontmatter == "" {
+		return content, nil
+	}
 
+	existing := map[string]interface{}{}
+	err = yaml.Unmarshal([]byte(oldFrontmatter), &existing)
 	if err != nil {
-		return ""
+		return "", errors.Wrap(err, fmt.Sprintf("Error while reading existing frontmatter"))
+	}
+	for existingKey := range existing {
+		for _, key := range keys {
+			if strings.EqualFold(existingKey, key) {
+				delete(existing, existingKey)
+			}
+		}
+	}
+	if len(existing) == 0 {
+		return body, nil
+	}
+
+	newFrontmatter, err := yaml.Marshal(existing)
+	if err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("Error while marshalling frontmatter to YAML"))
+	}
+	return fmt.Sprintf("---\n%s---\n\n%s", newFrontmatter, body), nil
+}
+
+// addFrontmatterParamsToFile adds the params to the frontmatter of an already composed local file
+func addFrontmatterParamsToFile(localPath string, params yaml.MapSlice) error {
+
+	c, err := ioutil.ReadFile(localPath)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error reading local markup file %s", localPath))
+	}
+
+	content, err := addFrontmatterParams(string(c), params)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error expanding frontmatter for %s", localPath))
+	}
+
+	err = ioutil.WriteFile(localPath, []byte(content), standardFilemode)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error writing local markup file %s", localPath))
 	}
+	return nil
+}
 
-	if !strings.Contains(u.Scheme, "http") {
+// getPageURL returns the URL Hugo renders the content file at. Since Monako uses ugly URLs,
+// "docs/page.md" becomes "/docs/page.html" and the section index "docs/_index.md" becomes "/docs.html"
+func getPageURL(contentDir string, localPath string) string {
+	relativePath, err := filepath.Rel(contentDir, localPath)
+	if err != nil {
 		return ""
 	}
+	return "/" + getPagePath(filepath.ToSlash(relativePath))
+}
 
-	// This works for Github and Gitlab
-	middlePath := "blob"
+// getPagePath returns the path of the rendered page of a slash separated content path
+func getPagePath(contentPath string) string {
+	base := strings.TrimSuffix(contentPath, path.Ext(contentPath))
+	if path.Base(base) == sectionIndexName {
+		base = path.Dir(base)
+	}
+	return base + ".html"
+}
 
-	// Bitbucket does it differently
-	if strings.Contains(u.Host, "bitbucket") {
-		middlePath = "src"
+// frontmatterHasKey returns true if the frontmatter contains the key. Like in Hugo, keys are case insensitive.
+func frontmatterHasKey(frontmatter map[string]interface{}, key string) bool {
+	for existingKey := range frontmatter {
+		if strings.EqualFold(existingKey, key) {
+			return true
+		}
 	}
+	return false
+}
 
-	u.Path = path.Join(u.Path, middlePath, branch, remotePath)
-	return u.String()
+// getFrontmatterValue returns the value of the key in the frontmatter. Like in Hugo, keys are case insensitive.
+func getFrontmatterValue(frontmatter map[string]interface{}, key string) (interface{}, bool) {
+	for existingKey, value := range frontmatter {
+		if strings.EqualFold(existingKey, key) {
+			return value, true
+		}
+	}
+	return nil, false
 }
 
-func getWebLinkForGitCommit(gitURL string, commitID string) string {
-	// URLs for checkout have .git suffix
-	gitURL = strings.TrimSuffix(gitURL, ".git")
-	u, err := url.Parse(gitURL)
+// readFrontmatter returns the frontmatter parameters of a composed document
+func readFrontmatter(localPath string) (map[string]interface{}, error) {
 
+	content, err := ioutil.ReadFile(localPath)
 	if err != nil {
-		return ""
+		return nil, errors.Wrap(err, fmt.Sprintf("Error reading local markup file %s", localPath))
 	}
 
-	if !strings.Contains(u.Scheme, "http") {
-		return ""
+	frontmatter, _, err := splitFrontmatterAndBody(string(content))
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error reading frontmatter of %s", localPath))
+	}
+
+	params := map[string]interface{}{}
+	err = yaml.Unmarshal([]byte(frontmatter), &params)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error reading frontmatter of %s", localPath))
+	}
+	return params, nil
+}
+
+func splitFrontmatterAndBody(content string) (frontmatter string, body string, err error) {
+	contentFrontmatter, err := pageparser.ParseFrontMatterAndContent(strings.NewReader(content))
+	if err != nil {
+		return "", "", errors.Wrap(err, fmt.Sprintf("While splitting frontmatter for content: %s", content))
+	}
+
+	// No frontmatter found, return old content
+	if contentFrontmatter.Content == nil {
+		return "", content, nil
 	}
 
-	// This works for Github and Gitlab
-	middlePath := "commit"
+	// Empty frontmatter, don't return it as "{}"
+	if len(contentFrontmatter.FrontMatter) == 0 {
+		return "", string(contentFrontmatter.Content), nil
+	}
 
-	// Bitbucket does it differently
-	if strings.Contains(u.Host, "bitbucket") {
-		middlePath = "commits"
+	contentMarshaled, err := yaml.Marshal(contentFrontmatter.FrontMatter)
+	if err != nil {
+		return "", "", errors.Wrap(err, fmt.Sprintf("Error while marshalling frontmatter to YAML"))
 	}
 
-	u.Path = path.Join(u.Path, middlePath, commitID)
-	return u.String()
+	return string(contentMarshaled), string(contentFrontmatter.Content), nil
 }
@@ -3,7 +3,6 @@ package compose
 // run: MONAKO_TEST_REPO="$HOME/temp/monako-testrepos/monako-test" go test ./pkg/compose -run TestExpandFrontmatter
 
 import (
-	"fmt"
 	"path/filepath"
 	"testing"
 	"time"
@@ -187,72 +186,6 @@ Also on new line`
 	})
 }
 
-func TestGetWebLink(t *testing.T) {
-
-	cases := []struct {
-		gitURL, branch, remotePath, Expected string
-	}{
-		{"https://github.com/snipem/monako-test.git",
-			"master", "test_doc_asciidoc.adoc",
-			"https://github.com/snipem/monako-test/blob/master/test_doc_asciidoc.adoc"},
-
-		{"https://gitlab.com/snipem/monako-test.git",
-			"test-branch", "README.md",
-			"https://gitlab.com/snipem/monako-test/blob/test-branch/README.md"},
-
-		{"https://bitbucket.org/snipem/monako-test.git",
-			"develop", "README.md",
-			"https://bitbucket.org/snipem/monako-test/src/develop/README.md"},
-
-		{"/file/local",
-			"develop", "README.md",
-			""},
-
-		{"git@github.com:snipem/monako-test.git",
-			"master", "README.md",
-			""},
-	}
-
-	for _, tc := range cases {
-		t.Run(fmt.Sprintf("%s, %s, %s -> %s", tc.gitURL, tc.branch, tc.remotePath, tc.Expected), func(t *testing.T) {
-			assert.Equal(t, tc.Expected, getWebLinkForFileInGit(tc.gitURL, tc.branch, tc.remotePath))
-		})
-	}
-}
-
-func TestGetCommitWebLink(t *testing.T) {
-
-	cases := []struct {
-		gitURL, commitID, Expected string
-	}{
-		{"https://github.com/snipem/monako-test.git",
-			"b744ffe4761cb3a282dcb30ac23b129ec19c9a53",
-			"https://github.com/snipem/monako-test/commit/b744ffe4761cb3a282dcb30ac23b129ec19c9a53"},
-
-		{"https://gitlab.com/snipem/monako-test.git",
-			"1559b863ff3a9cc1c077ebc480215fd54b621693",
-			"https://gitlab.com/snipem/monako-test/commit/1559b863ff3a9cc1c077ebc480215fd54b621693"},
-
-		{"https://bitbucket.org/snipem/monako-test.git",
-			"e99f32612df02ee18de15bd42326a10e4195be3d",
-			"https://bitbucket.org/snipem/monako-test/commits/e99f32612df02ee18de15bd42326a10e4195be3d"},
-
-		{"/file/local",
-			"commitID4711",
-			""},
-
-		{"git@github.com:snipem/monako-test.git",
-			"commitID4711",
-			""},
-	}
-
-	for _, tc := range cases {
-		t.Run(fmt.Sprintf("%s, %s -> %s", tc.gitURL, tc.commitID, tc.Expected), func(t *testing.T) {
-			assert.Equal(t, tc.Expected, getWebLinkForGitCommit(tc.gitURL, tc.commitID))
-		})
-	}
-}
-
 func TestCommitInfo(t *testing.T) {
 
 	testConfig, _ := getTestConfig(t)
@@ -315,4 +248,105 @@ func TestExpandFrontmatter(t *testing.T) {
 
 	})
 
+	t.Run("Expand Frontmatter with contributors", func(t *testing.T) {
+		firstDate := time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC)
+		file := &OriginFile{
+			LocalPath:  "localpath",
+			RemotePath: "remotepath",
+			parentOrigin: &Origin{Branch: "master",
+				URL:       "http://gitrepo.git",
+				SourceDir: "sourcedir",
+				TargetDir: "targetdir",
+			},
+			Commit: &OriginFileCommit{
+				Hash: "abc",
+				Author: OriginFileCommitter{
+					Email: "mail@mail.com",
+					Name:  "commiter name"},
+				Date:      time.Now(),
+				FirstDate: firstDate,
+				Contributors: []OriginFileContributor{
+					{Name: "commiter name", Email: "mail@mail.com", Commits: 3},
+					{Name: "other name", Email: "other@mail.com", Commits: 1},
+				},
+			},
+		}
+
+		result, err := file.ExpandFrontmatter("# Document")
+		assert.NoError(t, err)
+		assert.Contains(t, result, "date: \"2019-03-01T12:00:00Z\"")
+		assert.Contains(t, result, "MonakoGitFirstCommitDate: \"2019-03-01T12:00:00Z\"")
+		assert.Contains(t, result, "- name: other name\n  email: other@mail.com\n  commits: 1")
+		assert.Contains(t, result, "contributors:\n- commiter name\n- other name")
+	})
+
+	t.Run("Expand Frontmatter with edit links", func(t *testing.T) {
+		newFile := func(origin *Origin) *OriginFile {
+			return &OriginFile{
+				LocalPath:    "localpath",
+				RemotePath:   "docs/README.md",
+				parentOrigin: origin,
+				Commit:       &OriginFileCommit{Hash: "abc"},
+			}
+		}
+
+		result, err := newFile(&Origin{Branch: "develop", URL: "https://github.com/snipem/monako.git"}).ExpandFrontmatter("# Document")
+		assert.NoError(t, err)
+		assert.Contains(t, result, "MonakoGitURL: https://github.com/snipem/monako/blob/develop/docs/README.md")
+		assert.Contains(t, result, "MonakoGitURLEdit: https://github.com/snipem/monako/edit/develop/docs/README.md")
+		assert.Contains(t, result, "MonakoGitURLHistory: https://github.com/snipem/monako/commits/develop/docs/README.md")
+
+		result, err = newFile(&Origin{Tag: "v1.0.0", URL: "https://github.com/snipem/monako.git"}).ExpandFrontmatter("# Document")
+		assert.NoError(t, err)
+		assert.NotContains(t, result, "MonakoGitURLEdit", "Tags can't be edited")
+
+		result, err = newFile(&Origin{Branch: "develop", URL: "https://github.com/snipem/monako.git", DisableEditLinks: true}).ExpandFrontmatter("# Document")
+		assert.NoError(t, err)
+		assert.NotContains(t, result, "MonakoGitURLEdit")
+		assert.Contains(t, result, "MonakoGitURL: ", "View source link is kept")
+
+		result, err = newFile(&Origin{Branch: "develop", URL: "https://github.com/snipem/monako.git", ForgeTemplates: ForgeTemplates{
+			Edit: "https://cms.example.com/edit?file={{.Path}}&branch={{.Ref}}",
+		}}).ExpandFrontmatter("# Document")
+		assert.NoError(t, err)
+		assert.Contains(t, result, "MonakoGitURLEdit: https://cms.example.com/edit?file=docs/README.md&branch=develop")
+
+		withoutCommit := newFile(&Origin{Branch: "develop", URL: "https://github.com/snipem/monako.git"})
+		withoutCommit.Commit = nil
+		result, err = withoutCommit.ExpandFrontmatter("# Document")
+		assert.NoError(t, err)
+		assert.Contains(t, result, "MonakoGitURLEdit: https://github.com/snipem/monako/edit/develop/docs/README.md", "Edit links don't need commit info")
+		assert.Contains(t, result, "MonakoGitURL: https://github.com/snipem/monako/blob/develop/docs/README.md", "View source links don't need commit info")
+		assert.Contains(t, result, "MonakoGitURLHistory: https://github.com/snipem/monako/commits/develop/docs/README.md")
+		assert.NotContains(t, result, "MonakoGitLastCommitHash")
+	})
+
+	t.Run("Expand Frontmatter with history", func(t *testing.T) {
+		file := &OriginFile{
+			LocalPath:    "localpath",
+			RemotePath:   "remotepath",
+			parentOrigin: &Origin{Branch: "master", URL: "https://github.com/snipem/monako-test.git"},
+			Commit: &OriginFileCommit{
+				Hash:   "abc",
+				Author: OriginFileCommitter{Email: "mail@mail.com", Name: "commiter name"},
+				History: []OriginFileHistoryCommit{{
+					Hash:    "abc",
+					Subject: "Fix typo",
+					Author:  OriginFileCommitter{Email: "mail@mail.com", Name: "commiter name"},
+					Date:    time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC),
+				}},
+			},
+		}
+
+		result, err := file.ExpandFrontmatter("# Document")
+		assert.NoError(t, err)
+		assert.Contains(t, result, `MonakoGitHistory:
+- hash: abc
+  subject: Fix typo
+  author: commiter name
+  email: mail@mail.com
+  date: "2019-03-01T12:00:00Z"
+  url: https://github.com/snipem/monako-test/commit/abc`)
+	})
+
 }
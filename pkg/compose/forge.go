@@ -0,0 +1,387 @@
+package compose
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Types of the forges hosting Git repositories, their web interfaces differ in the layout of links
+const (
+	ForgeGitHub          = "github"
+	ForgeGitLab          = "gitlab"
+	ForgeBitbucket       = "bitbucket"
+	ForgeBitbucketServer = "bitbucketserver"
+	ForgeGitea           = "gitea"
+	ForgeAzure           = "azure"
+	ForgeGerrit          = "gerrit"
+)
+
+// Types of refs used in links
+const (
+	refTypeBranch = "branch"
+	refTypeTag    = "tag"
+	refTypeCommit = "commit"
+)
+
+// ForgeTemplates are Go templates for links to the web interface of a forge. The templates can use
+// .Base (scheme and host), .Project (path of the repository), .Repo (web URL of the repository),
+// .Ref, .RefType ("branch", "tag" or "commit"), .Path and .Commit. Empty templates produce no links.
+type ForgeTemplates struct {
+	// File is the link to view a file at a ref
+	File string `yaml:"file,omitempty"`
+	// Commit is the link to a commit
+	Commit string `yaml:"commit,omitempty"`
+	// Edit is the link to edit a file on a branch
+	Edit string `yaml:"edit,omitempty"`
+	// History is the link to the commits of a file
+	History string `yaml:"history,omitempty"`
+}
+
+// forgeType is a built-in forge
+type forgeType struct {
+	templates ForgeTemplates
+	// project returns the project path of the web interface for the path of a clone URL
+	project func(clonePath string) string
+	// repo returns the web URL of the repository
+	repo func(base string, project string) string
+}
+
+var forgeTypes = map[string]forgeType{
+	ForgeGitHub: {
+		templates: ForgeTemplates{
+			File:    "{{.Repo}}/blob/{{.Ref}}/{{.Path}}",
+			Commit:  "{{.Repo}}/commit/{{.Commit}}",
+			Edit:    "{{.Repo}}/edit/{{.Ref}}/{{.Path}}",
+			History: "{{.Repo}}/commits/{{.Ref}}/{{.Path}}",
+		},
+	},
+	ForgeGitLab: {
+		templates: ForgeTemplates{
+			File:    "{{.Repo}}/-/blob/{{.Ref}}/{{.Path}}",
+			Commit:  "{{.Repo}}/-/commit/{{.Commit}}",
+			Edit:    "{{.Repo}}/-/edit/{{.Ref}}/{{.Path}}",
+			History: "{{.Repo}}/-/commits/{{.Ref}}/{{.Path}}",
+		},
+	},
+	ForgeBitbucket: {
+		templates: ForgeTemplates{
+			File:    "{{.Repo}}/src/{{.Ref}}/{{.Path}}",
+			Commit:  "{{.Repo}}/commits/{{.Commit}}",
+			Edit:    "{{.Repo}}/src/{{.Ref}}/{{.Path}}?mode=edit",
+			History: "{{.Repo}}/history-node/{{.Ref}}/{{.Path}}",
+		},
+	},
+	ForgeBitbucketServer: {
+		templates: ForgeTemplates{
+			File:    "{{.Repo}}/browse/{{.Path}}?at={{.Ref}}",
+			Commit:  "{{.Repo}}/commits/{{.Commit}}",
+			Edit:    "{{.Repo}}/browse/{{.Path}}?at={{.Ref}}&mode=edit",
+			History: "{{.Repo}}/history/{{.Path}}?until={{.Ref}}",
+		},
+		// Clone URLs are like /scm/PROJECT/repo.git, the web interface is /projects/PROJECT/repos/repo
+		project: func(clonePath string) string {
+			return strings.TrimPrefix(clonePath, "scm/")
+		},
+		repo: func(base string, project string) string {
+			parts := strings.SplitN(project, "/", 2)
+			if len(parts) != 2 {
+				return base + "/" + project
+			}
+			return fmt.Sprintf("%s/projects/%s/repos/%s", base, parts[0], parts[1])
+		},
+	},
+	ForgeGitea: {
+		templates: ForgeTemplates{
+			File:    "{{.Repo}}/src/{{.RefType}}/{{.Ref}}/{{.Path}}",
+			Commit:  "{{.Repo}}/commit/{{.Commit}}",
+			Edit:    "{{.Repo}}/_edit/{{.Ref}}/{{.Path}}",
+			History: "{{.Repo}}/commits/{{.RefType}}/{{.Ref}}/{{.Path}}",
+		},
+	},
+	ForgeAzure: {
+		templates: ForgeTemplates{
+			File:    `{{.Repo}}?path=/{{.Path}}&version={{if eq .RefType "tag"}}GT{{else if eq .RefType "commit"}}GC{{else}}GB{{end}}{{.Ref}}`,
+			Commit:  "{{.Repo}}/commit/{{.Commit}}",
+			Edit:    "{{.Repo}}?path=/{{.Path}}&version=GB{{.Ref}}&_a=contents",
+			History: `{{.Repo}}?path=/{{.Path}}&version={{if eq .RefType "tag"}}GT{{else if eq .RefType "commit"}}GC{{else}}GB{{end}}{{.Ref}}&_a=history`,
+		},
+		// SSH clone URLs are like v3/org/project/repo, HTTPS clone URLs like org/project/_git/repo
+		project: func(clonePath string) string {
+			parts := strings.Split(strings.TrimPrefix(clonePath, "v3/"), "/")
+			if len(parts) == 3 {
+				return strings.Join([]string{parts[0], parts[1], "_git", parts[2]}, "/")
+			}
+			return clonePath
+		},
+	},
+	ForgeGerrit: {
+		templates: ForgeTemplates{
+			File:    "{{.Repo}}/+/{{.Ref}}/{{.Path}}",
+			Commit:  "{{.Repo}}/+/{{.Commit}}",
+			Edit:    "{{.Base}}/admin/repos/edit/repo/{{.Project}}/branch/refs/heads/{{.Ref}}/file/{{.Path}}",
+			History: "{{.Repo}}/+log/{{.Ref}}/{{.Path}}",
+		},
+		// Authenticated clone URLs are prefixed with /a/
+		project: func(clonePath string) string {
+			return strings.TrimPrefix(clonePath, "a/")
+		},
+		// Files and commits are shown by Gitiles
+		repo: func(base string, project string) string {
+			return base + "/plugins/gitiles/" + project
+		},
+	},
+}
+
+// forge builds links to the web interface of the forge hosting a repository
+type forge struct {
+	file    *template.Template
+	commit  *template.Template
+	edit    *template.Template
+	history *template.Template
+
+	data forgeLink
+}
+
+// forgeLink is the data of a link template
+type forgeLink struct {
+	Base    string
+	Project string
+	Repo    string
+	Ref     string
+	RefType string
+	Path    string
+	Commit  string
+}
+
+// scpURLPattern matches SCP like SSH URLs, e.g. git@github.com:snipem/monako.git
+var scpURLPattern = regexp.MustCompile(`^(?:[\w.-]+@)?([\w.-]+):(.+)$`)
+
+// newForge returns the forge of the repository with the clone URL. Without a forge type it is
+// guessed from the host. Custom templates replace the templates of the forge type. Returns nil
+// for repositories without a web interface, like local repositories.
+func newForge(gitURL string, forgeName string, custom ForgeTemplates) (*forge, error) {
+
+	base, clonePath := splitCloneURL(gitURL)
+	if base == "" {
+		return nil, nil
+	}
+
+	if forgeName == "" {
+		forgeName = guessForge(base, clonePath)
+	}
+
+	builtin, known := forgeTypes[forgeName]
+	if !known {
+		return nil, fmt.Errorf("Unknown forge '%s'", forgeName)
+	}
+
+	project := clonePath
+	if builtin.project != nil {
+		project = builtin.project(clonePath)
+	}
+
+	repo := base + "/" + project
+	if builtin.repo != nil {
+		repo = builtin.repo(base, project)
+	}
+
+	templates := builtin.templates
+	if custom.File != "" {
+		templates.File = custom.File
+	}
+	if custom.Commit != "" {
+		templates.Commit = custom.Commit
+	}
+	if custom.Edit != "" {
+		templates.Edit = custom.Edit
+	}
+	if custom.History != "" {
+		templates.History = custom.History
+	}
+
+	f := &forge{data: forgeLink{Base: base, Project: project, Repo: repo}}
+	for _, link := range []struct {
+		text     string
+		template **template.Template
+	}{
+		{templates.File, &f.file},
+		{templates.Commit, &f.commit},
+		{templates.Edit, &f.edit},
+		{templates.History, &f.history},
+	} {
+		if link.text == "" {
+			continue
+		}
+		parsed, err := template.New("link").Parse(link.text)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error parsing link template '%s'", link.text))
+		}
+		*link.template = parsed
+	}
+
+	return f, nil
+}
+
+// splitCloneURL returns the scheme and host of the web interface and the path of the repository
+// for HTTP(S), Git and SSH clone URLs. Ports and users are dropped, since web interfaces are
+// served on the default HTTPS port.
+func splitCloneURL(gitURL string) (base string, clonePath string) {
+
+	var host, repoPath string
+	scheme := "https"
+
+	if match := scpURLPattern.FindStringSubmatch(gitURL); match != nil && !strings.Contains(gitURL, "://") {
+		host, repoPath = match[1], match[2]
+	} else {
+		u, err := url.Parse(gitURL)
+		if err != nil {
+			return "", ""
+		}
+		switch u.Scheme {
+		case "http", "https":
+			scheme, host = u.Scheme, u.Host
+		case "ssh", "git":
+			host = u.Hostname()
+		default:
+			return "", ""
+		}
+		repoPath = u.Path
+	}
+
+	repoPath = strings.TrimSuffix(strings.Trim(repoPath, "/"), ".git")
+	if host == "" || repoPath == "" {
+		return "", ""
+	}
+
+	// Azure DevOps serves SSH on its own host
+	if host == "ssh.dev.azure.com" {
+		host = "dev.azure.com"
+	}
+
+	return scheme + "://" + host, repoPath
+}
+
+// guessForge returns the type of the forge by its host and clone path
+func guessForge(base string, clonePath string) string {
+	host := strings.ToLower(base)
+	switch {
+	case strings.Contains(host, "gitlab"):
+		return ForgeGitLab
+	case strings.HasPrefix(clonePath, "scm/"):
+		return ForgeBitbucketServer
+	case strings.Contains(host, "bitbucket"):
+		return ForgeBitbucket
+	case strings.Contains(host, "dev.azure.com"), strings.Contains(host, "visualstudio.com"):
+		return ForgeAzure
+	case strings.Contains(host, "gitea"), strings.Contains(host, "codeberg"):
+		return ForgeGitea
+	case strings.Contains(host, "gerrit"), strings.Contains(host, "googlesource"):
+		return ForgeGerrit
+	default:
+		// Most repositories are hosted on GitHub or a forge with the same layout
+		return ForgeGitHub
+	}
+}
+
+// getFileURL returns the link to view the file at the ref
+func (f *forge) getFileURL(ref string, refType string, remotePath string) string {
+	if f == nil {
+		return ""
+	}
+	return f.execute(f.file, ref, refType, remotePath, "")
+}
+
+// getCommitURL returns the link to the commit
+func (f *forge) getCommitURL(commit string) string {
+	if f == nil {
+		return ""
+	}
+	return f.execute(f.commit, "", "", "", commit)
+}
+
+// getEditURL returns the link to edit the file on the branch
+func (f *forge) getEditURL(ref string, refType string, remotePath string) string {
+	if f == nil {
+		return ""
+	}
+	return f.execute(f.edit, ref, refType, remotePath, "")
+}
+
+// getHistoryURL returns the link to the commits of the file
+func (f *forge) getHistoryURL(ref string, refType string, remotePath string) string {
+	if f == nil {
+		return ""
+	}
+	return f.execute(f.history, ref, refType, remotePath, "")
+}
+
+// execute returns the link of the template with the escaped ref and path, empty if there is no template
+func (f *forge) execute(link *template.Template, ref string, refType string, remotePath string, commit string) string {
+
+	if link == nil {
+		return ""
+	}
+
+	data := f.data
+	data.Ref = escapePath(ref)
+	data.RefType = refType
+	data.Path = escapePath(remotePath)
+	data.Commit = commit
+
+	var result strings.Builder
+	err := link.Execute(&result, data)
+	if err != nil {
+		log.Warnf("Can't create link for %s: %s", f.data.Repo, err)
+		return ""
+	}
+	return result.String()
+}
+
+// escapePath escapes the segments of a slash separated path for URLs
+func escapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+	return strings.Join(segments, "/")
+}
+
+// getForge returns the forge of the repository with the clone URL, which is the origin itself
+// or one of its submodules. Errors are reported by checkForge before composing.
+func (origin *Origin) getForge(gitURL string) *forge {
+	f, err := newForge(gitURL, origin.Forge, origin.ForgeTemplates)
+	if err != nil {
+		origin.getLogger().Warnf("Can't create links for %s: %s", gitURL, err)
+		return nil
+	}
+	return f
+}
+
+// checkForge returns an error if the forge of the origin is unknown or a link template is invalid
+func (origin *Origin) checkForge() error {
+	_, err := newForge(origin.URL, origin.Forge, origin.ForgeTemplates)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error in forge of origin %s", origin.URL))
+	}
+	return nil
+}
+
+// getWebRefType returns the type of the ref returned by getWebRef
+func (origin *Origin) getWebRefType() string {
+	switch {
+	case origin.Commit != "":
+		return refTypeCommit
+	case origin.Tag != "":
+		return refTypeTag
+	case origin.Branch != "", origin.resolvedBranch != "":
+		return refTypeBranch
+	default:
+		return refTypeCommit
+	}
+}
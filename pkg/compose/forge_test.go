@@ -0,0 +1,160 @@
+package compose
+
+// run: go test ./pkg/compose -run TestForge
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetWebLink(t *testing.T) {
+
+	cases := []struct {
+		gitURL, branch, remotePath, Expected string
+	}{
+		{"https://github.com/snipem/monako-test.git",
+			"master", "test_doc_asciidoc.adoc",
+			"https://github.com/snipem/monako-test/blob/master/test_doc_asciidoc.adoc"},
+
+		{"https://gitlab.com/snipem/monako-test.git",
+			"test-branch", "README.md",
+			"https://gitlab.com/snipem/monako-test/-/blob/test-branch/README.md"},
+
+		{"https://bitbucket.org/snipem/monako-test.git",
+			"develop", "README.md",
+			"https://bitbucket.org/snipem/monako-test/src/develop/README.md"},
+
+		{"/file/local",
+			"develop", "README.md",
+			""},
+
+		{"git@github.com:snipem/monako-test.git",
+			"master", "README.md",
+			"https://github.com/snipem/monako-test/blob/master/README.md"},
+
+		{"ssh://git@gitlab.example.com:2222/org/docs.git",
+			"master", "docs/My Page.md",
+			"https://gitlab.example.com/org/docs/-/blob/master/docs/My%20Page.md"},
+	}
+
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("%s, %s, %s -> %s", tc.gitURL, tc.branch, tc.remotePath, tc.Expected), func(t *testing.T) {
+			forge, err := newForge(tc.gitURL, "", ForgeTemplates{})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, forge.getFileURL(tc.branch, refTypeBranch, tc.remotePath))
+		})
+	}
+}
+
+func TestGetCommitWebLink(t *testing.T) {
+
+	cases := []struct {
+		gitURL, commitID, Expected string
+	}{
+		{"https://github.com/snipem/monako-test.git",
+			"b744ffe4761cb3a282dcb30ac23b129ec19c9a53",
+			"https://github.com/snipem/monako-test/commit/b744ffe4761cb3a282dcb30ac23b129ec19c9a53"},
+
+		{"https://gitlab.com/snipem/monako-test.git",
+			"1559b863ff3a9cc1c077ebc480215fd54b621693",
+			"https://gitlab.com/snipem/monako-test/-/commit/1559b863ff3a9cc1c077ebc480215fd54b621693"},
+
+		{"https://bitbucket.org/snipem/monako-test.git",
+			"e99f32612df02ee18de15bd42326a10e4195be3d",
+			"https://bitbucket.org/snipem/monako-test/commits/e99f32612df02ee18de15bd42326a10e4195be3d"},
+
+		{"/file/local",
+			"commitID4711",
+			""},
+
+		{"git@github.com:snipem/monako-test.git",
+			"commitID4711",
+			"https://github.com/snipem/monako-test/commit/commitID4711"},
+	}
+
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("%s, %s -> %s", tc.gitURL, tc.commitID, tc.Expected), func(t *testing.T) {
+			forge, err := newForge(tc.gitURL, "", ForgeTemplates{})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, forge.getCommitURL(tc.commitID))
+		})
+	}
+}
+
+func TestForges(t *testing.T) {
+
+	cases := []struct {
+		forge, gitURL               string
+		ref, refType                string
+		file, commit, edit, history string
+	}{
+		{"", "https://git.example.com/scm/DOCS/manual.git", "master", refTypeBranch,
+			"https://git.example.com/projects/DOCS/repos/manual/browse/README.md?at=master",
+			"https://git.example.com/projects/DOCS/repos/manual/commits/abc",
+			"https://git.example.com/projects/DOCS/repos/manual/browse/README.md?at=master&mode=edit",
+			"https://git.example.com/projects/DOCS/repos/manual/history/README.md?until=master"},
+
+		{ForgeGitea, "git@git.example.com:org/docs.git", "v1.0", refTypeTag,
+			"https://git.example.com/org/docs/src/tag/v1.0/README.md",
+			"https://git.example.com/org/docs/commit/abc",
+			"https://git.example.com/org/docs/_edit/v1.0/README.md",
+			"https://git.example.com/org/docs/commits/tag/v1.0/README.md"},
+
+		{"", "https://org@dev.azure.com/org/project/_git/docs", "main", refTypeBranch,
+			"https://dev.azure.com/org/project/_git/docs?path=/README.md&version=GBmain",
+			"https://dev.azure.com/org/project/_git/docs/commit/abc",
+			"https://dev.azure.com/org/project/_git/docs?path=/README.md&version=GBmain&_a=contents",
+			"https://dev.azure.com/org/project/_git/docs?path=/README.md&version=GBmain&_a=history"},
+
+		{"", "git@ssh.dev.azure.com:v3/org/project/docs", "abc", refTypeCommit,
+			"https://dev.azure.com/org/project/_git/docs?path=/README.md&version=GCabc",
+			"https://dev.azure.com/org/project/_git/docs/commit/abc",
+			"https://dev.azure.com/org/project/_git/docs?path=/README.md&version=GBabc&_a=contents",
+			"https://dev.azure.com/org/project/_git/docs?path=/README.md&version=GCabc&_a=history"},
+
+		{ForgeGerrit, "ssh://jane@review.example.com:29418/platform/docs", "master", refTypeBranch,
+			"https://review.example.com/plugins/gitiles/platform/docs/+/master/README.md",
+			"https://review.example.com/plugins/gitiles/platform/docs/+/abc",
+			"https://review.example.com/admin/repos/edit/repo/platform/docs/branch/refs/heads/master/file/README.md",
+			"https://review.example.com/plugins/gitiles/platform/docs/+log/master/README.md"},
+
+		{ForgeGitHub, "https://github.com/snipem/monako.git", "release/1.0", refTypeBranch,
+			"https://github.com/snipem/monako/blob/release/1.0/README.md",
+			"https://github.com/snipem/monako/commit/abc",
+			"https://github.com/snipem/monako/edit/release/1.0/README.md",
+			"https://github.com/snipem/monako/commits/release/1.0/README.md"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.gitURL, func(t *testing.T) {
+			forge, err := newForge(tc.gitURL, tc.forge, ForgeTemplates{})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.file, forge.getFileURL(tc.ref, tc.refType, "README.md"))
+			assert.Equal(t, tc.commit, forge.getCommitURL("abc"))
+			assert.Equal(t, tc.edit, forge.getEditURL(tc.ref, tc.refType, "README.md"))
+			assert.Equal(t, tc.history, forge.getHistoryURL(tc.ref, tc.refType, "README.md"))
+		})
+	}
+
+	t.Run("Custom templates", func(t *testing.T) {
+		forge, err := newForge("https://git.example.com/org/docs.git", ForgeGitLab, ForgeTemplates{
+			File: "https://docs-browser.example.com/{{.Project}}?ref={{.Ref}}&file={{.Path}}",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "https://docs-browser.example.com/org/docs?ref=master&file=README.md", forge.getFileURL("master", refTypeBranch, "README.md"))
+		assert.Equal(t, "https://git.example.com/org/docs/-/commit/abc", forge.getCommitURL("abc"), "Templates of the forge are kept")
+	})
+
+	t.Run("Unknown forge", func(t *testing.T) {
+		_, err := newForge("https://git.example.com/org/docs.git", "sourceforge", ForgeTemplates{})
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid template", func(t *testing.T) {
+		origin := NewOrigin("https://git.example.com/org/docs.git", "master", ".", "docs")
+		origin.ForgeTemplates.File = "{{.Repo"
+		assert.Error(t, origin.checkForge())
+	})
+}
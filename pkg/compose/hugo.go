@@ -13,6 +13,59 @@ import (
 const monakoMenuDirectory = "monako_menu_directory"
 const themeName = "monako-book"
 
+// contributorsTaxonomy is the Hugo taxonomy listing the documents of each contributor
+const contributorsTaxonomy = "contributors"
+
+// editLinkPartial is the partial injected into the footer of the theme, that links to the editor
+// of the document in the forge. It replaces the site wide BookRepo and BookEditPath of the theme.
+const editLinkPartial = "layouts/partials/docs/inject/footer.html"
+
+// editLinkTemplate renders the MonakoGitURLEdit of the page
+const editLinkTemplate = `{{ with .Params.MonakoGitURLEdit }}
+<div class="flex flex-wrap justify-between">
+  <div>
+    <a class="flex align-center" href="{{ . }}" target="_blank" rel="noopener">
+      <img src="{{ "svg/edit.svg" | relURL }}" class="book-icon" alt="Edit" />
+      <span>Edit this page</span>
+    </a>
+  </div>
+</div>
+{{ end }}
+`
+
+// versionHeadPartial is the partial injected into the head of the theme, that links older versions
+// of a page to the same page in the latest version
+const versionHeadPartial = "layouts/partials/docs/inject/head.html"
+
+// versionHeadTemplate renders the MonakoCanonicalURL of the page
+const versionHeadTemplate = `{{ with .Params.MonakoCanonicalURL }}
+<link rel="canonical" href="{{ strings.TrimPrefix "/" . | absURL }}" />
+{{ end }}
+`
+
+// versionSwitcherPartial is the partial injected above the menu of the theme, that switches between
+// the versions of a versioned origin
+const versionSwitcherPartial = "layouts/partials/docs/inject/menu-before.html"
+
+// versionSwitcherTemplate renders the versions of the version group of the page from the version data
+const versionSwitcherTemplate = `{{ with .Params.MonakoVersionGroup }}{{ with index $.Site.Data.monako.versions . }}
+<div class="monako-versions">
+  <select aria-label="Version" onchange="window.location.href = this.value">
+    {{ range .versions }}
+    <option value="{{ strings.TrimPrefix "/" .url | relURL }}"{{ if eq .name $.Params.MonakoVersion }} selected{{ end }}>{{ .name }}{{ if .latest }} (latest){{ end }}</option>
+    {{ end }}
+  </select>
+</div>
+{{ end }}{{ end }}
+`
+
+// themePartials are the partials written to the Hugo working dir, which override the partials of the theme
+var themePartials = map[string]string{
+	editLinkPartial:        editLinkTemplate,
+	versionHeadPartial:     versionHeadTemplate,
+	versionSwitcherPartial: versionSwitcherTemplate,
+}
+
 // extractTheme extracts the Monako Theme to the Hugo Working Directory
 func extractTheme(hugoWorkingDir string) error {
 	themesDir := filepath.Join(hugoWorkingDir, "themes")
@@ -45,6 +98,11 @@ func createMonakoStructureInHugoFolder(composeConfig *Config, menuconfig string)
 		return errors.Wrap(err, fmt.Sprintf("Error creating Hugo config"))
 	}
 
+	err = createThemePartials(composeConfig.HugoWorkingDir)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error creating theme partials"))
+	}
+
 	err = createMenuConfig(composeConfig, menuconfig)
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("Error creating Monako menu config"))
@@ -52,26 +110,57 @@ func createMonakoStructureInHugoFolder(composeConfig *Config, menuconfig string)
 	return nil
 }
 
+// createMenuConfig copies the menu config to the menu bundle. If the menu is generated, the menu
+// config is an optional header of the generated menu. It is not used if the config has a menu.
 func createMenuConfig(composeConfig *Config, menuconfig string) error {
 
-	dir := filepath.Join(composeConfig.ContentWorkingDir, monakoMenuDirectory)
-	dst := filepath.Join(dir, "index.md")
-	err := os.MkdirAll(dir, os.FileMode(0744))
-	if err != nil {
-		return errors.Wrap(err, fmt.Sprintf("Error creating menu dir %s", dir))
+	if len(composeConfig.Menu) > 0 {
+		// The menu of the config replaces the menu config and is written after composing
+		return composeConfig.writeMenu(menuFrontmatter)
 	}
 
 	data, err := ioutil.ReadFile(menuconfig)
+	if err == nil {
+		composeConfig.menuConfigFile = menuconfig
+	}
+	if composeConfig.GenerateMenu && os.IsNotExist(err) {
+		data, err = []byte(menuFrontmatter), nil
+	}
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("Error reading menu config %s", menuconfig))
 	}
 
-	err = ioutil.WriteFile(dst, data, 0644)
-	if err != nil {
-		return errors.Wrap(err, fmt.Sprintf("Error writing menu config to %s", dst))
+	if composeConfig.GenerateMenu {
+		composeConfig.menuHeader = string(data)
+	}
+	return composeConfig.writeMenu(string(data))
+
+}
+
+// createThemePartials writes the partials rendering the edit links, canonical links and version
+// switchers of the documents, which override the partials of the theme
+func createThemePartials(hugoWorkingDir string) error {
+	for name, template := range themePartials {
+		partial := filepath.Join(hugoWorkingDir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(partial), standardFilemode)
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(partial, []byte(template), standardFilemode)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error writing partial %s", partial))
+		}
 	}
 	return nil
+}
 
+// getContributorsTaxonomy returns the taxonomy of the Hugo config with a page per contributor, if
+// contributors are added to the documents
+func getContributorsTaxonomy(composeConfig *Config) string {
+	if !composeConfig.Contributors {
+		return ""
+	}
+	return fmt.Sprintf("# A page per contributor\ncontributor = \"%s\"\n", contributorsTaxonomy)
 }
 
 // TODO Make MonakoGitLinks configurable
@@ -111,14 +200,19 @@ trace = false
 # this is needed for rendering section 0 to h1
 showtitle = "true"
 
+# Default taxonomies
+[taxonomies]
+category = "categories"
+tag = "tags"
+%s
+
 [params]
 # See: https://github.com/snipem/monako-book#configuration for settings
 BookToC = true
 BookLogo = '%s'
 BookMenuBundle = '/%s'
 BookSection = 'docs'
-#BookRepo = 'https://github.com/alex-shpak/hugo-book'
-#BookEditPath = 'edit/master/exampleSite/content'
+# BookRepo and BookEditPath are replaced by MonakoGitURLEdit of each document, rendered by the partial docs/inject/footer
 BookDateFormat = 'Jan 2, 2006'
 BookSearch = true
 BookComments = true
@@ -129,7 +223,7 @@ BookTheme = 'auto'
 MonakoGitLinks = true
 MonakoDisableGitCommit = %v
 
-	`, composeConfig.BaseURL, composeConfig.Title, themeName, composeConfig.Logo, monakoMenuDirectory, composeConfig.DisableCommitInfo)
+	`, composeConfig.BaseURL, composeConfig.Title, themeName, getContributorsTaxonomy(composeConfig), composeConfig.Logo, monakoMenuDirectory, composeConfig.DisableCommitInfo)
 
 	err := os.MkdirAll(composeConfig.HugoWorkingDir, standardFilemode)
 	if err != nil {
@@ -3,6 +3,7 @@ package compose
 // run: go test  ./pkg/compose -run TestCreatePage
 
 import (
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
@@ -22,6 +23,19 @@ func TestCreatePage(t *testing.T) {
 
 		// Check if Hugo content dir has been created
 		assert.FileExists(t, filepath.Join(config.HugoWorkingDir, "config.toml"))
+
+		hugoConfig, err := ioutil.ReadFile(filepath.Join(config.HugoWorkingDir, "config.toml"))
+		assert.NoError(t, err)
+		assert.Contains(t, string(hugoConfig), "tag = \"tags\"")
+		assert.NotContains(t, string(hugoConfig), "contributor = ", "No taxonomy without contributors")
+
+		config.Contributors = true
+		defer func() { config.Contributors = false }()
+		err = createHugoConfig(config)
+		assert.NoError(t, err)
+		hugoConfig, err = ioutil.ReadFile(filepath.Join(config.HugoWorkingDir, "config.toml"))
+		assert.NoError(t, err)
+		assert.Contains(t, string(hugoConfig), "contributor = \"contributors\"", "Taxonomy for contributors")
 	})
 
 	t.Run("Create Monako structure", func(t *testing.T) {
@@ -35,6 +49,22 @@ func TestCreatePage(t *testing.T) {
 		// Check if theme has been extracted
 		assert.FileExists(t, filepath.Join(config.HugoWorkingDir, "themes", themeName, "theme.toml"))
 
+		// Check if the edit links are rendered
+		partial, err := ioutil.ReadFile(filepath.Join(config.HugoWorkingDir, filepath.FromSlash(editLinkPartial)))
+		assert.NoError(t, err)
+		assert.Contains(t, string(partial), ".Params.MonakoGitURLEdit")
+
+		// Check if the canonical links and the version switcher are rendered
+		head, err := ioutil.ReadFile(filepath.Join(config.HugoWorkingDir, filepath.FromSlash(versionHeadPartial)))
+		assert.NoError(t, err)
+		assert.Contains(t, string(head), "<link rel=\"canonical\"")
+		assert.Contains(t, string(head), ".Params.MonakoCanonicalURL")
+
+		switcher, err := ioutil.ReadFile(filepath.Join(config.HugoWorkingDir, filepath.FromSlash(versionSwitcherPartial)))
+		assert.NoError(t, err)
+		assert.Contains(t, string(switcher), "index $.Site.Data.monako.versions .")
+		assert.Contains(t, string(switcher), ".Params.MonakoVersion")
+
 	})
 
 }
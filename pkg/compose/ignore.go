@@ -0,0 +1,136 @@
+package compose
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-billy.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/format/gitattributes"
+	"gopkg.in/src-d/go-git.v4/plumbing/format/gitignore"
+)
+
+// monakoIgnoreFile is the file in the docdir of an origin listing files that are not composed, in gitignore syntax
+const monakoIgnoreFile = ".monakoignore"
+
+// gitAttributesFile is the file in the origin repository containing the attributes of files
+const gitAttributesFile = ".gitattributes"
+
+// exportIgnoreAttribute marks files that are not exported by git archive, they are not composed either
+const exportIgnoreAttribute = "export-ignore"
+
+// originIgnore contains the rules of the origin repository itself for files that are not composed
+type originIgnore struct {
+	// patterns are the patterns of the .monakoignore file, nil if there is none
+	patterns gitignore.Matcher
+	// attributes are the .gitattributes patterns of the walked directories, in ascending priority
+	attributes []gitattributes.MatchAttribute
+}
+
+// loadIgnoreRules reads the .monakoignore file in the docdir and the .gitattributes files of
+// the parent directories of the docdir. The .gitattributes files in the docdir and below are
+// read while walking the directories.
+func (origin *Origin) loadIgnoreRules(filesystem billy.Filesystem) error {
+
+	origin.ignore = &originIgnore{}
+	docDir := splitRemotePath(origin.SourceDir)
+
+	patterns, err := readIgnorePatterns(filesystem, docDir)
+	if err != nil {
+		return err
+	}
+	if patterns != nil {
+		origin.printf("Applying '%s' of '%s'\n", path.Join(origin.SourceDir, monakoIgnoreFile), origin.URL)
+		origin.ignore.patterns = gitignore.NewMatcher(patterns)
+	}
+
+	for i := 0; i < len(docDir); i++ {
+		err = origin.readDirAttributes(filesystem, path.Join(docDir[:i]...))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readIgnorePatterns returns the patterns of the .monakoignore file in the dir, nil if there is none
+func readIgnorePatterns(filesystem billy.Filesystem, dir []string) ([]gitignore.Pattern, error) {
+
+	ignoreFile := path.Join(append(dir, monakoIgnoreFile)...)
+	f, err := filesystem.Open(ignoreFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error opening %s", ignoreFile))
+	}
+	defer f.Close()
+
+	var patterns []gitignore.Pattern
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, gitignore.ParsePattern(line, dir))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error reading %s", ignoreFile))
+	}
+	return patterns, nil
+}
+
+// readDirAttributes adds the patterns of the .gitattributes file in the dir, if there is one
+func (origin *Origin) readDirAttributes(filesystem billy.Filesystem, dir string) error {
+
+	if origin.ignore == nil {
+		return nil
+	}
+
+	dirPath := splitRemotePath(dir)
+	// Like in Git, only the root .gitattributes may define macros
+	attributes, err := gitattributes.ReadAttributesFile(filesystem, dirPath, gitAttributesFile, len(dirPath) == 0)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error reading %s", path.Join(dir, gitAttributesFile)))
+	}
+	origin.ignore.attributes = append(origin.ignore.attributes, attributes...)
+	return nil
+}
+
+// isIgnored returns true if the file or directory is ignored by the .monakoignore file or
+// marked as export-ignore in the .gitattributes
+func (origin *Origin) isIgnored(remotePath string, isDir bool) bool {
+
+	if origin.ignore == nil {
+		return false
+	}
+
+	filePath := splitRemotePath(remotePath)
+
+	if origin.ignore.patterns != nil && origin.ignore.patterns.Match(filePath, isDir) {
+		origin.getLogger().Debugf("'%s' is ignored by %s", remotePath, monakoIgnoreFile)
+		return true
+	}
+
+	if len(origin.ignore.attributes) > 0 {
+		results, _ := gitattributes.NewMatcher(origin.ignore.attributes).Match(filePath, []string{exportIgnoreAttribute})
+		if attribute, found := results[exportIgnoreAttribute]; found && attribute.IsSet() {
+			origin.getLogger().Debugf("'%s' is marked as %s", remotePath, exportIgnoreAttribute)
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitRemotePath splits a slash separated path of the origin into its elements
+func splitRemotePath(remotePath string) []string {
+	remotePath = strings.TrimPrefix(path.Clean("/"+remotePath), "/")
+	if remotePath == "" {
+		return nil
+	}
+	return strings.Split(remotePath, "/")
+}
@@ -0,0 +1,70 @@
+package compose
+
+// run: go test ./pkg/compose -run TestIgnore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-billy.v4"
+	"gopkg.in/src-d/go-billy.v4/memfs"
+	"gopkg.in/src-d/go-billy.v4/util"
+)
+
+func TestIgnoreRules(t *testing.T) {
+
+	filesystem := createTestFilesystem(t, map[string]string{
+		".gitattributes":           "docs/generated export-ignore\n*.draft.md export-ignore\n",
+		"docs/.monakoignore":       "# Not published\ninternal/\n*.wip.md\n!keep.wip.md\n/TODO.md\n",
+		"docs/README.md":           "# Published",
+		"docs/TODO.md":             "# Ignored in docdir root",
+		"docs/guide/TODO.md":       "# Published, pattern is anchored",
+		"docs/guide/next.wip.md":   "# Ignored",
+		"docs/guide/keep.wip.md":   "# Published again",
+		"docs/guide/idea.draft.md": "# Export ignored",
+		"docs/internal/secret.md":  "# Ignored directory",
+		"docs/generated/api.md":    "# Export ignored directory",
+		"docs/api/.gitattributes":  "v1.md export-ignore\n",
+		"docs/api/v1.md":           "# Export ignored by nested attributes",
+		"docs/api/v2.md":           "# Published",
+		"other/.monakoignore":      "*.md\n",
+	})
+
+	origin := NewOrigin("memory", "master", "docs", "docs/ignore")
+	origin.config = &Config{DisableCommitInfo: true}
+	origin.FileWhitelist = []string{".md"}
+
+	assert.NoError(t, origin.loadIgnoreRules(filesystem))
+
+	var remotePaths []string
+	for _, file := range origin.getMatchingFiles(origin.SourceDir, filesystem) {
+		remotePaths = append(remotePaths, file.RemotePath)
+	}
+
+	assert.ElementsMatch(t, []string{
+		"docs/README.md",
+		"docs/guide/TODO.md",
+		"docs/guide/keep.wip.md",
+		"docs/api/v2.md",
+	}, remotePaths)
+
+	t.Run("Without ignore files", func(t *testing.T) {
+		origin := NewOrigin("memory", "master", "other", "docs/ignore")
+		origin.config = &Config{DisableCommitInfo: true}
+		origin.FileWhitelist = []string{".md"}
+		filesystem := createTestFilesystem(t, map[string]string{"other/README.md": "# Published"})
+
+		assert.NoError(t, origin.loadIgnoreRules(filesystem))
+		assert.Nil(t, origin.ignore.patterns)
+		assert.Len(t, origin.getMatchingFiles(origin.SourceDir, filesystem), 1)
+	})
+}
+
+// createTestFilesystem returns a virtual filesystem containing the given files
+func createTestFilesystem(t *testing.T, files map[string]string) billy.Filesystem {
+	filesystem := memfs.New()
+	for name, content := range files {
+		assert.NoError(t, util.WriteFile(filesystem, name, []byte(content), standardFilemode))
+	}
+	return filesystem
+}
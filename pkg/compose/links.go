@@ -0,0 +1,239 @@
+package compose
+
+import (
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/src-d/go-billy.v4"
+)
+
+// markdownLinkPattern matches the targets of inline links and images like [text](target "title")
+var markdownLinkPattern = regexp.MustCompile(`(!?\[[^\]]*\]\()(<[^>]*>|[^)\s]+)`)
+
+// markdownReferencePattern matches the targets of link reference definitions like [id]: target
+var markdownReferencePattern = regexp.MustCompile(`^( {0,3}\[[^\]]+\]:[ \t]*)(<[^>]*>|\S+)`)
+
+// asciidocLinkPattern matches the targets of link, xref and image macros like link:target[text]
+var asciidocLinkPattern = regexp.MustCompile(`\b(link:|xref:|image::?)([^\s\[]+)\[`)
+
+// htmlLinkPattern matches the targets of HTML links and images in Markdown like <img src="target">
+var htmlLinkPattern = regexp.MustCompile(`(<(?:a|img|source|video|audio)\b[^>]*?\s(?:href|src)=["'])([^"']+)`)
+
+// rewriteLinks rewrites the relative links of the document, which break after composing. Links to
+// composed files point to their published page, links to other files of the repository point to
+// the forge at the composed commit. Links in code blocks and code spans are kept.
+func (file *OriginFile) rewriteLinks(content string, filesystem billy.Filesystem) string {
+	return file.replaceLinks(content, func(macro string, target string) (string, string) {
+		rewritten := file.rewriteLink(target, filesystem)
+		// Cross references to other documents become plain links to their pages
+		if macro == "xref:" && rewritten != target {
+			macro = "link:"
+		}
+		return macro, rewritten
+	})
+}
+
+// replaceLinks replaces the macros or prefixes and the targets of all links of the document
+// outside of code blocks and code spans
+func (file *OriginFile) replaceLinks(content string, replace func(macro string, target string) (string, string)) string {
+
+	format := file.GetFormat()
+	lines := strings.Split(content, "\n")
+	inCodeBlock := false
+
+	for i, line := range lines {
+
+		if isCodeBlockDelimiter(line, format) {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			continue
+		}
+
+		switch format {
+		case Markdown:
+			line = replaceLinkTargets(markdownLinkPattern, line, getCodeSpans(line), replace)
+			line = replaceLinkTargets(markdownReferencePattern, line, nil, replace)
+			line = replaceLinkTargets(htmlLinkPattern, line, getCodeSpans(line), replace)
+		case Asciidoc:
+			line = replaceLinkTargets(asciidocLinkPattern, line, nil, replace)
+		}
+		lines[i] = line
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// replaceLinkTargets replaces the prefix and target submatches of all matches of the pattern,
+// except for matches starting in one of the code spans
+func replaceLinkTargets(pattern *regexp.Regexp, line string, codeSpans [][2]int, replace func(prefix string, target string) (string, string)) string {
+
+	var result strings.Builder
+	last := 0
+	for _, match := range pattern.FindAllStringSubmatchIndex(line, -1) {
+		if isInCodeSpan(match[0], codeSpans) {
+			continue
+		}
+		prefix, target := line[match[2]:match[3]], line[match[4]:match[5]]
+
+		// Targets with spaces can be enclosed in angle brackets
+		enclosed := strings.HasPrefix(target, "<") && strings.HasSuffix(target, ">")
+		if enclosed {
+			target = target[1 : len(target)-1]
+		}
+
+		prefix, target = replace(prefix, target)
+		if enclosed {
+			target = "<" + target + ">"
+		}
+
+		result.WriteString(line[last:match[2]])
+		result.WriteString(prefix)
+		result.WriteString(target)
+		last = match[5]
+	}
+	result.WriteString(line[last:])
+	return result.String()
+}
+
+// getCodeSpans returns the start and end of the inline code spans of a Markdown line like `code`.
+// A code span ends with the next backtick string of the same length as the one it starts with.
+func getCodeSpans(line string) [][2]int {
+
+	// Start and end of all backtick strings of the line
+	var runs [][2]int
+	for i := 0; i < len(line); i++ {
+		if line[i] != '`' {
+			continue
+		}
+		start := i
+		for i < len(line) && line[i] == '`' {
+			i++
+		}
+		runs = append(runs, [2]int{start, i})
+	}
+
+	var spans [][2]int
+	for i := 0; i < len(runs); i++ {
+		length := runs[i][1] - runs[i][0]
+		for j := i + 1; j < len(runs); j++ {
+			if runs[j][1]-runs[j][0] == length {
+				spans = append(spans, [2]int{runs[i][0], runs[j][1]})
+				i = j
+				break
+			}
+		}
+		// Backtick strings without a closing one are literal
+	}
+	return spans
+}
+
+// isInCodeSpan returns true if the position of the line is part of one of the code spans
+func isInCodeSpan(position int, codeSpans [][2]int) bool {
+	for _, span := range codeSpans {
+		if position >= span[0] && position < span[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// isCodeBlockDelimiter returns true if the line starts or ends a code block of the format
+func isCodeBlockDelimiter(line string, format string) bool {
+	trimmed := strings.TrimSpace(line)
+	switch format {
+	case Markdown:
+		return strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+	case Asciidoc:
+		return len(trimmed) >= 4 && (strings.Trim(trimmed, "-") == "" || strings.Trim(trimmed, ".") == "" || strings.Trim(trimmed, "+") == "")
+	default:
+		return false
+	}
+}
+
+// rewriteLink returns the new target of a relative link of the document. Absolute links, anchors
+// and links to files that don't exist are returned as they are.
+func (file *OriginFile) rewriteLink(target string, filesystem billy.Filesystem) string {
+
+	remotePath, suffix, ok := file.resolveLink(target)
+	if !ok {
+		return target
+	}
+
+	origin := file.parentOrigin
+	if composed, found := origin.composedFiles[remotePath]; found {
+		return file.getRelativeURL(composed) + suffix
+	}
+
+	if _, err := filesystem.Stat(remotePath); err != nil {
+		return target
+	}
+
+	remote, repoPath, commit := origin.getComposedLocation(remotePath)
+	if commit == "" {
+		return target
+	}
+	link := origin.getForge(remote).getFileURL(commit, refTypeCommit, repoPath)
+	if link == "" {
+		return target
+	}
+	return link + suffix
+}
+
+// resolveLink returns the path in the repository of a relative link of the document and the query
+// and anchor of the link. Links with a scheme or host, absolute links, anchors and links leaving the
+// repository can't be resolved.
+func (file *OriginFile) resolveLink(target string) (remotePath string, suffix string, ok bool) {
+
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return "", "", false
+	}
+
+	// Keep the query and the anchor of the link
+	linkPath := target
+	if i := strings.IndexAny(target, "?#"); i >= 0 {
+		linkPath, suffix = target[:i], target[i:]
+	}
+	linkPath, err = url.PathUnescape(linkPath)
+	if err != nil || linkPath == "" || strings.HasPrefix(linkPath, "/") {
+		return "", "", false
+	}
+
+	remotePath = path.Join(path.Dir(file.RemotePath), linkPath)
+	if remotePath == ".." || strings.HasPrefix(remotePath, "../") {
+		return "", "", false
+	}
+	return remotePath, suffix, true
+}
+
+// getRelativeURL returns the URL of the published composed file relative to the page of this document
+func (file *OriginFile) getRelativeURL(target *OriginFile) string {
+
+	targetPath := target.LocalPath
+	if target.GetFormat() != "" {
+		// Monako uses ugly URLs, "page.md" is published as "page.html"
+		targetPath = filepath.FromSlash(getPagePath(filepath.ToSlash(targetPath)))
+	}
+
+	// Links are relative to the published page, section indexes are published above their directory
+	pageDir := filepath.Dir(filepath.FromSlash(getPagePath(filepath.ToSlash(file.LocalPath))))
+	relativePath, err := filepath.Rel(pageDir, targetPath)
+	if err != nil {
+		return ""
+	}
+	return escapePath(filepath.ToSlash(relativePath))
+}
+
+// getComposedLocation returns the remote URL, the path in this repository and the commit of a
+// path of the origin as it was composed. Paths of submodules are located in the submodule.
+func (origin *Origin) getComposedLocation(remotePath string) (remote string, repoPath string, commit string) {
+	if submodule := origin.getSubmodule(remotePath); submodule != nil {
+		return submodule.URL, strings.TrimPrefix(remotePath, submodule.Path+"/"), submodule.Commit
+	}
+	return origin.URL, remotePath, origin.resolvedCommit
+}
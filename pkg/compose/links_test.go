@@ -0,0 +1,97 @@
+package compose
+
+// run: go test ./pkg/compose -run TestRewriteLinks
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRewriteLinks(t *testing.T) {
+
+	filesystem := createTestFilesystem(t, map[string]string{
+		"docs/guide/README.md":      "# Guide",
+		"docs/guide/images/pic.png": "PNG",
+		"docs/api/README.md":        "# API",
+		"docs/manual.adoc":          "= Manual",
+		"docs/My Page.md":           "# My Page",
+		"src/main.go":               "package main",
+		"docs/internal/notes.txt":   "Not composed",
+	})
+
+	origin := NewOrigin("https://github.com/snipem/monako.git", "master", "docs", "docs/product")
+	origin.resolvedCommit = "abc"
+	origin.config = &Config{ContentWorkingDir: filepath.Join("compose", "content")}
+	origin.FileWhitelist = []string{".md", ".adoc", ".png"}
+	origin.Files = origin.getMatchingFiles(origin.SourceDir, filesystem)
+	origin.composedFiles = map[string]*OriginFile{}
+	for i := range origin.Files {
+		origin.composedFiles[origin.Files[i].RemotePath] = &origin.Files[i]
+	}
+	guide := origin.composedFiles["docs/guide/README.md"]
+	manual := origin.composedFiles["docs/manual.adoc"]
+
+	t.Run("Markdown", func(t *testing.T) {
+		cases := []struct {
+			content, want string
+		}{
+			{"[API](../api/README.md#usage)", "[API](../api/README.html#usage)"},
+			{"[API](<../api/README.md> \"Title\")", "[API](<../api/README.html> \"Title\")"},
+			{"![Picture](images/pic.png)", "![Picture](images/pic.png)"},
+			{"[Page](../My%20Page.md)", "[Page](../My%20Page.html)"},
+			{"[Code](../../src/main.go)", "[Code](https://github.com/snipem/monako/blob/abc/src/main.go)"},
+			{"[Notes](../internal/notes.txt?plain=1)", "[Notes](https://github.com/snipem/monako/blob/abc/docs/internal/notes.txt?plain=1)"},
+			{"[Manual](../manual.adoc)", "[Manual](../manual.html)"},
+			{"[ref]: ../api/README.md", "[ref]: ../api/README.html"},
+			{"<a href=\"../api/README.md\">API</a>", "<a href=\"../api/README.html\">API</a>"},
+			{"<img width=\"50\" src='images/pic.png'>", "<img width=\"50\" src='images/pic.png'>"},
+			{"[Web](https://example.com/README.md)", "[Web](https://example.com/README.md)"},
+			{"[Mail](mailto:docs@example.com)", "[Mail](mailto:docs@example.com)"},
+			{"[Anchor](#top)", "[Anchor](#top)"},
+			{"[Root](/docs/README.md)", "[Root](/docs/README.md)"},
+			{"[Missing](missing.md)", "[Missing](missing.md)"},
+			{"[Outside](../../../outside.md)", "[Outside](../../../outside.md)"},
+			{"```\n[API](../api/README.md)\n```", "```\n[API](../api/README.md)\n```"},
+			{"Write `[API](../api/README.md)` for [API](../api/README.md)", "Write `[API](../api/README.md)` for [API](../api/README.html)"},
+			{"``<a href=\"../api/README.md\">`` and `` ` ``", "``<a href=\"../api/README.md\">`` and `` ` ``"},
+			{"[`API`](../api/README.md)", "[`API`](../api/README.html)"},
+			{"Single ` [API](../api/README.md)", "Single ` [API](../api/README.html)"},
+		}
+		for _, tc := range cases {
+			assert.Equal(t, tc.want, guide.rewriteLinks(tc.content, filesystem), tc.content)
+		}
+	})
+
+	t.Run("Asciidoc", func(t *testing.T) {
+		cases := []struct {
+			content, want string
+		}{
+			{"See xref:guide/README.md#setup[Guide].", "See link:guide/README.html#setup[Guide]."},
+			{"link:../src/main.go[Code]", "link:https://github.com/snipem/monako/blob/abc/src/main.go[Code]"},
+			{"image::guide/images/pic.png[Picture]", "image::guide/images/pic.png[Picture]"},
+			{"xref:other.adoc#section[Other]", "xref:other.adoc#section[Other]"},
+			{"----\nlink:../src/main.go[Code]\n----", "----\nlink:../src/main.go[Code]\n----"},
+		}
+		for _, tc := range cases {
+			assert.Equal(t, tc.want, manual.rewriteLinks(tc.content, filesystem), tc.content)
+		}
+	})
+
+	t.Run("Section index", func(t *testing.T) {
+		localPath := guide.LocalPath
+		defer func() { guide.LocalPath = localPath }()
+
+		// Section indexes are published above their directory
+		guide.LocalPath = filepath.Join(filepath.Dir(localPath), "_index.md")
+		assert.Equal(t, "[API](api/README.html)", guide.rewriteLinks("[API](../api/README.md)", filesystem))
+		assert.Equal(t, "![Picture](guide/images/pic.png)", guide.rewriteLinks("![Picture](images/pic.png)", filesystem))
+	})
+
+	t.Run("Origins without forge", func(t *testing.T) {
+		origin.URL = "/local/repository"
+		defer func() { origin.URL = "https://github.com/snipem/monako.git" }()
+		assert.Equal(t, "[Code](../../src/main.go)", guide.rewriteLinks("[Code](../../src/main.go)", filesystem))
+	})
+}
@@ -0,0 +1,103 @@
+package compose
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-billy.v4"
+	"gopkg.in/src-d/go-billy.v4/osfs"
+	"gopkg.in/src-d/go-git.v4"
+)
+
+// OriginTypeLocal is the type of origins that are read from a local directory
+const OriginTypeLocal = "local"
+
+// OpenLocalDir returns the local directory of the origin as a filesystem. If the directory is
+// part of a Git repository, the repository is used to obtain Git commit information.
+func (origin *Origin) OpenLocalDir() (filesystem billy.Filesystem, err error) {
+
+	origin.printf("\nReading local directory '%s' ...\n", origin.URL)
+
+	if origin.Branch != "" || origin.Tag != "" || origin.Commit != "" {
+		return nil, fmt.Errorf("Local origin %s can't have a branch, tag or commit", origin.URL)
+	}
+
+	dir, err := filepath.Abs(origin.URL)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error resolving local directory %s", origin.URL))
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error reading local directory %s", dir))
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("Local origin %s is not a directory", dir)
+	}
+
+	if !origin.config.DisableCommitInfo {
+		err = origin.openEnclosingRepo(dir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return osfs.New(dir), nil
+}
+
+// openEnclosingRepo opens the Git repository containing the local directory, if there is one
+func (origin *Origin) openEnclosingRepo(dir string) error {
+
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err == git.ErrRepositoryNotExists {
+		origin.printf("'%s' is not part of a Git repository, skipping commit info\n", origin.URL)
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error opening enclosing repository of %s", dir))
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error opening worktree of enclosing repository of %s", dir))
+	}
+
+	repoDir, err := getRelativeRealPath(worktree.Filesystem.Root(), dir)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error locating %s in its enclosing repository", dir))
+	}
+
+	err = origin.resolveHead(repo)
+	if err != nil {
+		return err
+	}
+
+	origin.getLogger().Debugf("Local origin %s is '%s' in repository %s", origin.URL, repoDir, worktree.Filesystem.Root())
+	origin.repo = repo
+	origin.repoDir = repoDir
+	return nil
+}
+
+// getRelativeRealPath returns the slash separated path of target relative to base with all symlinks
+// resolved, since the repository root may be reported with resolved symlinks
+func getRelativeRealPath(base string, target string) (string, error) {
+
+	base, err := filepath.EvalSymlinks(base)
+	if err != nil {
+		return "", err
+	}
+	target, err = filepath.EvalSymlinks(target)
+	if err != nil {
+		return "", err
+	}
+
+	relativePath, err := filepath.Rel(base, target)
+	if err != nil {
+		return "", err
+	}
+	if relativePath == "." {
+		return "", nil
+	}
+	return filepath.ToSlash(relativePath), nil
+}
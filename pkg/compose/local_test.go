@@ -0,0 +1,81 @@
+package compose
+
+// run: go test ./pkg/compose -run TestLocal
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Flaque/filet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalOrigin(t *testing.T) {
+
+	// Outside of the Monako repository, otherwise it would be the enclosing repository
+	dir := filet.TmpDir(t, "")
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "api", "v1"), standardFilemode))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "api", "v1", "reference.md"), []byte("# API Reference"), standardFilemode))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "api", "build.log"), []byte("Build log"), standardFilemode))
+
+	origin := NewOrigin(dir, "", "api", "docs/api")
+	origin.Type = OriginTypeLocal
+	config, _ := getTestConfig(t, *origin)
+
+	err := config.Compose()
+	assert.NoError(t, err)
+
+	assert.FileExists(t, filepath.Join(config.ContentWorkingDir, "docs/api/v1/reference.md"))
+	assert.NoFileExists(t, filepath.Join(config.ContentWorkingDir, "docs/api/build.log"))
+	assert.Nil(t, config.Origins[0].Files[0].Commit, "No commit info outside of a repository")
+
+	t.Run("Fail on missing directory", func(t *testing.T) {
+		origin := NewOrigin(filepath.Join(dir, "missing"), "", ".", "docs/api")
+		origin.Type = OriginTypeLocal
+		config, _ := getTestConfig(t, *origin)
+
+		err := config.Compose()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "Error opening local origin")
+	})
+
+	t.Run("Fail on branch", func(t *testing.T) {
+		origin := NewOrigin(dir, "master", ".", "docs/api")
+		origin.Type = OriginTypeLocal
+		config, _ := getTestConfig(t, *origin)
+
+		err := config.Compose()
+		assert.Error(t, err)
+	})
+}
+
+func TestLocalOriginInRepository(t *testing.T) {
+
+	repoDir := createTestRepository(t, map[string]string{
+		"generated/docs/index.md": "# Generated",
+	})
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(repoDir, "generated/docs/uncommitted.md"), []byte("# Uncommitted"), standardFilemode))
+
+	origin := NewOrigin(filepath.Join(repoDir, "generated"), "", "docs", "docs/generated")
+	origin.Type = OriginTypeLocal
+	config, _ := getTestConfig(t, *origin)
+
+	err := config.Compose()
+	assert.NoError(t, err)
+
+	commits := map[string]*OriginFileCommit{}
+	for _, file := range config.Origins[0].Files {
+		commits[file.RemotePath] = file.Commit
+	}
+
+	if assert.NotNil(t, commits["docs/index.md"], "Commit info is taken from the enclosing repository") {
+		assert.Equal(t, "Monako Test", commits["docs/index.md"].Author.Name)
+	}
+	assert.Nil(t, commits["docs/uncommitted.md"])
+
+	content, err := ioutil.ReadFile(filepath.Join(config.ContentWorkingDir, "docs/generated/index.md"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "MonakoGitLastCommitAuthor: Monako Test")
+}
@@ -0,0 +1,129 @@
+package compose
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// mailmapFile is the file in the root of a repository mapping commit identities to authors
+const mailmapFile = ".mailmap"
+
+// mailmap maps the names and emails used in commits to the proper identities of their authors.
+// See: https://git-scm.com/docs/gitmailmap
+type mailmap struct {
+	// entries are keyed by the commit email and the optional commit name
+	entries map[string]mailmapEntry
+}
+
+// mailmapEntry is the proper identity of an author, empty fields are not replaced
+type mailmapEntry struct {
+	name  string
+	email string
+}
+
+// readMailmap returns the mailmap of the HEAD commit of the repository or nil if there is none
+func readMailmap(repo *git.Repository) (*mailmap, error) {
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error resolving HEAD"))
+	}
+
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error reading commit %s", head.Hash()))
+	}
+
+	file, err := commit.File(mailmapFile)
+	if err == object.ErrFileNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error reading %s", mailmapFile))
+	}
+
+	content, err := file.Contents()
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error reading %s", mailmapFile))
+	}
+
+	return parseMailmap(content), nil
+}
+
+// parseMailmap parses the lines of a mailmap. Lines have one of these forms:
+//
+//	Proper Name <commit@email>
+//	<proper@email> <commit@email>
+//	Proper Name <proper@email> <commit@email>
+//	Proper Name <proper@email> Commit Name <commit@email>
+func parseMailmap(content string) *mailmap {
+
+	m := &mailmap{entries: map[string]mailmapEntry{}}
+
+	for _, line := range strings.Split(content, "\n") {
+
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+
+		var names, emails []string
+		for {
+			start := strings.Index(line, "<")
+			end := strings.Index(line, ">")
+			if start < 0 || end < start {
+				break
+			}
+			names = append(names, strings.TrimSpace(line[:start]))
+			emails = append(emails, strings.TrimSpace(line[start+1:end]))
+			line = line[end+1:]
+		}
+
+		switch len(emails) {
+		case 1:
+			m.entries[mailmapKey("", emails[0])] = mailmapEntry{name: names[0]}
+		case 2:
+			m.entries[mailmapKey(names[1], emails[1])] = mailmapEntry{name: names[0], email: emails[0]}
+		}
+	}
+
+	return m
+}
+
+// mailmapKey returns the key of a commit identity, names and emails are matched case insensitive
+func mailmapKey(name string, email string) string {
+	return strings.ToLower(email) + "\x00" + strings.ToLower(name)
+}
+
+// resolve returns the proper name and email of the author of a commit. Entries with a commit
+// name take precedence over entries with only a commit email.
+func (m *mailmap) resolve(name string, email string) (string, string) {
+
+	if m == nil {
+		return name, email
+	}
+
+	entry, found := m.entries[mailmapKey(name, email)]
+	if !found {
+		entry, found = m.entries[mailmapKey("", email)]
+	}
+	if !found {
+		return name, email
+	}
+
+	if entry.name != "" {
+		name = entry.name
+	}
+	if entry.email != "" {
+		email = entry.email
+	}
+	return name, email
+}
+
+// getAuthor returns the author of the commit with the identity of the mailmap
+func (m *mailmap) getAuthor(commit *object.Commit) OriginFileCommitter {
+	name, email := m.resolve(commit.Author.Name, commit.Author.Email)
+	return OriginFileCommitter{Name: name, Email: email}
+}
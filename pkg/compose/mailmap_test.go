@@ -0,0 +1,68 @@
+package compose
+
+// run: go test ./pkg/compose -run TestMailmap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+func TestMailmap(t *testing.T) {
+
+	m := parseMailmap(`# Authors of the project
+Jane Doe <jane@example.com>
+<jane@example.com> <jane@private.example.com>
+Jane Doe <jane@example.com> Build Bot <bot@example.com>
+Joe Developer <joe@example.com> <JOE@old.example.com> # Old address
+`)
+
+	tests := []struct {
+		name, email         string
+		wantName, wantEmail string
+	}{
+		{"jane", "jane@example.com", "Jane Doe", "jane@example.com"},
+		{"jane", "jane@private.example.com", "jane", "jane@example.com"},
+		{"Build Bot", "bot@example.com", "Jane Doe", "jane@example.com"},
+		{"Other Bot", "bot@example.com", "Other Bot", "bot@example.com"},
+		{"joe", "joe@OLD.example.com", "Joe Developer", "joe@example.com"},
+		{"Unknown", "unknown@example.com", "Unknown", "unknown@example.com"},
+	}
+	for _, tt := range tests {
+		name, email := m.resolve(tt.name, tt.email)
+		assert.Equal(t, tt.wantName, name, "Name of %s <%s>", tt.name, tt.email)
+		assert.Equal(t, tt.wantEmail, email, "Email of %s <%s>", tt.name, tt.email)
+	}
+
+	t.Run("Without mailmap", func(t *testing.T) {
+		var m *mailmap
+		name, email := m.resolve("jane", "jane@example.com")
+		assert.Equal(t, "jane", name)
+		assert.Equal(t, "jane@example.com", email)
+	})
+}
+
+func TestMailmapInCommitIndex(t *testing.T) {
+
+	repoDir := createTestRepository(t, map[string]string{"README.md": "# First"})
+	commitTestFilesAs(t, repoDir, object.Signature{Name: "jane", Email: "jane@private.example.com"}, map[string]string{"README.md": "# Second"})
+	commitTestFilesAs(t, repoDir, object.Signature{Name: "Jane Doe", Email: "jane@example.com"}, map[string]string{
+		"README.md": "# Third",
+		mailmapFile: "<jane@example.com> <jane@private.example.com>\nJane Doe <jane@example.com>\n",
+	})
+
+	repo, err := git.PlainOpen(repoDir)
+	assert.NoError(t, err)
+
+	index, err := newCommitIndex(repo, []string{"README.md"}, commitIndexOptions{contributors: true})
+	assert.NoError(t, err)
+
+	commit := index.last["README.md"]
+	assert.Equal(t, "Jane Doe", commit.Author.Name)
+	assert.Equal(t, []OriginFileContributor{
+		{Name: "Jane Doe", Email: "jane@example.com", Commits: 2},
+		{Name: "Monako Test", Email: "monako@example.com", Commits: 1},
+	}, commit.Contributors, "Identities of the mailmap are merged")
+}
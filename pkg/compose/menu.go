@@ -0,0 +1,353 @@
+package compose
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// menuFrontmatter is the frontmatter of the menu bundle if no handwritten header is given
+const menuFrontmatter = "---\nheadless: true\n---\n"
+
+// filenamePrefixPattern matches ordering prefixes of file and directory names like "01-intro.md"
+var filenamePrefixPattern = regexp.MustCompile(`^(\d+)[-_. ]+(.+)$`)
+
+// indexDocumentNames are the names of documents that are the page of their directory
+var indexDocumentNames = []string{"_index", "index", "readme"}
+
+// menuNode is a page or a section of the menu
+type menuNode struct {
+	// label is the text of the menu entry
+	label string
+	// page is the path of the linked document relative to the content dir, empty for sections without page
+	page string
+	// name is the file or directory name used for ordering
+	name string
+	// weight is the frontmatter weight of the page, 0 if not set
+	weight int
+	// external is set if the page is the URL of an external link
+	external bool
+	// source describes where the entry is configured, empty for generated entries
+	source string
+
+	children []*menuNode
+	// sections are the child sections by their directory name
+	sections map[string]*menuNode
+}
+
+// getMenuTree returns the sections and pages of the composed documents. Every directory of the target
+// dirs and below them is a nested section.
+func (config *Config) getMenuTree() (*menuNode, error) {
+
+	root := &menuNode{sections: map[string]*menuNode{}}
+
+	for i := range config.Origins {
+		origin := &config.Origins[i]
+		targetDir := strings.Trim(path.Clean(filepath.ToSlash(origin.TargetDir)), "/")
+
+		for _, file := range origin.Files {
+			if file.GetFormat() == "" {
+				continue
+			}
+
+			relativePath, err := config.getContentPath(file.LocalPath)
+			if err != nil {
+				return nil, err
+			}
+
+			// The target dir and the directories below it are nested sections
+			section := root
+			for _, dir := range strings.Split(path.Dir(relativePath), "/") {
+				if dir != "" && dir != "." {
+					section = section.getSection(dir)
+				}
+			}
+
+			err = section.addPage(relativePath, file.LocalPath)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// Sections without page are titled like their generated section index. The title of the origin
+		// is already the title of a section index page in the target dir.
+		if section := root.findSection(targetDir); section != nil && section != root && origin.Title != "" && section.page == "" {
+			section.label = origin.Title
+		}
+	}
+
+	root.sort()
+	return root, nil
+}
+
+// getContentPath returns the slash separated path of a composed file relative to the content dir
+func (config *Config) getContentPath(localPath string) (string, error) {
+	relativePath, err := filepath.Rel(config.ContentWorkingDir, localPath)
+	if err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("Error locating %s in content dir", localPath))
+	}
+	return filepath.ToSlash(relativePath), nil
+}
+
+// findSection returns the section of a directory relative to the content dir or nil if the
+// directory has no documents
+func (node *menuNode) findSection(dir string) *menuNode {
+	section := node
+	for _, name := range strings.Split(strings.Trim(path.Clean("/"+dir), "/"), "/") {
+		if name == "" {
+			continue
+		}
+		child, found := section.sections[name]
+		if !found {
+			return nil
+		}
+		section = child
+	}
+	return section
+}
+
+// newMenuSection returns a section named by the last element of the directory
+func newMenuSection(dir string) *menuNode {
+	return &menuNode{
+		label:    prettifyName(path.Base(dir)),
+		name:     path.Base(dir),
+		sections: map[string]*menuNode{},
+	}
+}
+
+// getSection returns the child section of the directory and creates it if needed
+func (node *menuNode) getSection(dir string) *menuNode {
+	section, found := node.sections[dir]
+	if !found {
+		section = newMenuSection(dir)
+		node.sections[dir] = section
+		node.children = append(node.children, section)
+	}
+	return section
+}
+
+// addPage adds a composed document to the section. Index documents like README.md become the
+// page of the section itself.
+func (node *menuNode) addPage(page string, localPath string) error {
+
+	frontmatter, err := readFrontmatter(localPath)
+	if err != nil {
+		return err
+	}
+
+	name := path.Base(page)
+	baseName := strings.TrimSuffix(name, path.Ext(name))
+	weight := getWeight(frontmatter)
+	title, hasTitle := getFrontmatterValue(frontmatter, "title")
+	hasTitle = hasTitle && fmt.Sprint(title) != ""
+
+	if node.page == "" && node.name != "" && isIndexDocument(baseName) {
+		node.page = page
+		node.weight = weight
+		if hasTitle {
+			node.label = fmt.Sprint(title)
+		}
+		return nil
+	}
+
+	label := prettifyName(baseName)
+	if hasTitle {
+		label = fmt.Sprint(title)
+	}
+
+	node.children = append(node.children, &menuNode{
+		label:  label,
+		page:   page,
+		name:   name,
+		weight: weight,
+	})
+	return nil
+}
+
+// sort orders the children by their weight, the number prefix of their name and their name
+func (node *menuNode) sort() {
+	sort.SliceStable(node.children, func(i, j int) bool {
+		return lessMenuNode(node.children[i], node.children[j])
+	})
+	for _, child := range node.children {
+		child.sort()
+	}
+}
+
+// lessMenuNode returns true if a is listed before b. Nodes with a weight come first.
+func lessMenuNode(a *menuNode, b *menuNode) bool {
+
+	if a.weight != b.weight {
+		if a.weight == 0 || b.weight == 0 {
+			return b.weight == 0
+		}
+		return a.weight < b.weight
+	}
+
+	aPrefix, aHasPrefix := getNamePrefix(a.name)
+	bPrefix, bHasPrefix := getNamePrefix(b.name)
+	if aHasPrefix != bHasPrefix {
+		return aHasPrefix
+	}
+	if aPrefix != bPrefix {
+		return aPrefix < bPrefix
+	}
+
+	return strings.ToLower(a.name) < strings.ToLower(b.name)
+}
+
+// render writes the children of the node as nested Markdown list
+func (node *menuNode) render(out *strings.Builder, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, child := range node.children {
+		label := escapeMenuLabel(child.label)
+		if child.sections != nil {
+			label = "**" + label + "**"
+		}
+		if child.external {
+			fmt.Fprintf(out, "%s- [%s](%s)\n", indent, label, child.page)
+		} else if child.page != "" {
+			fmt.Fprintf(out, "%s- [%s]({{< relref \"/%s\" >}})\n", indent, label, child.page)
+		} else {
+			fmt.Fprintf(out, "%s- %s\n", indent, label)
+		}
+		child.render(out, depth+1)
+	}
+}
+
+// getSources returns a description of the origin of every line rendered for the children of the
+// node. Children without source inherit the source of their parent or are generated.
+func (node *menuNode) getSources(inherited string) []string {
+	var sources []string
+	for _, child := range node.children {
+		source := child.source
+		if source == "" {
+			source = inherited
+		}
+		if source == "" {
+			sources = append(sources, fmt.Sprintf("generated menu entry '%s'", child.label))
+		} else {
+			sources = append(sources, source)
+		}
+		sources = append(sources, child.getSources(source)...)
+	}
+	return sources
+}
+
+// writeComposedMenu writes the menu of the config or the menu generated from the composed
+// documents to the menu bundle
+func (config *Config) writeComposedMenu() error {
+
+	if len(config.Menu) > 0 {
+		return config.writeConfiguredMenu()
+	}
+	if config.GenerateMenu {
+		return config.writeGeneratedMenu()
+	}
+	return nil
+}
+
+// writeGeneratedMenu writes the menu generated from the composed documents below the handwritten
+// header of the menu config to the menu bundle
+func (config *Config) writeGeneratedMenu() error {
+
+	tree, err := config.getMenuTree()
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error generating menu"))
+	}
+
+	var menu strings.Builder
+	if strings.TrimSpace(config.menuHeader) == "" {
+		menu.WriteString(menuFrontmatter)
+	} else {
+		menu.WriteString(strings.TrimRight(config.menuHeader, "\n") + "\n")
+	}
+	menu.WriteString("\n")
+	// The lines of the handwritten header are reported with the menu config
+	sources := make([]string, strings.Count(menu.String(), "\n"))
+	tree.render(&menu, 0)
+
+	err = config.writeMenu(menu.String())
+	config.menuSources = append(sources, tree.getSources("")...)
+	return err
+}
+
+// writeMenu writes the content of the menu bundle
+func (config *Config) writeMenu(content string) error {
+
+	dir := filepath.Join(config.ContentWorkingDir, monakoMenuDirectory)
+	dst := filepath.Join(dir, "index.md")
+	err := os.MkdirAll(dir, os.FileMode(0744))
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error creating menu dir %s", dir))
+	}
+
+	err = ioutil.WriteFile(dst, []byte(content), 0644)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error writing menu config to %s", dst))
+	}
+	config.menuSources = nil
+	return nil
+}
+
+// getWeight returns the weight of the frontmatter or 0 if it isn't set
+func getWeight(frontmatter map[string]interface{}) int {
+	value, found := getFrontmatterValue(frontmatter, "weight")
+	if !found {
+		return 0
+	}
+	switch weight := value.(type) {
+	case int:
+		return weight
+	case float64:
+		return int(weight)
+	default:
+		w, _ := strconv.Atoi(fmt.Sprint(weight))
+		return w
+	}
+}
+
+// getNamePrefix returns the ordering number prefix of a file or directory name
+func getNamePrefix(name string) (int, bool) {
+	match := filenamePrefixPattern.FindStringSubmatch(name)
+	if match == nil {
+		return 0, false
+	}
+	prefix, err := strconv.Atoi(match[1])
+	return prefix, err == nil
+}
+
+// isIndexDocument returns true if the document name without extension is the page of its directory
+func isIndexDocument(baseName string) bool {
+	for _, name := range indexDocumentNames {
+		if strings.EqualFold(baseName, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// prettifyName returns a label for a file or directory name like "01-getting_started" -> "Getting started"
+func prettifyName(name string) string {
+	if match := filenamePrefixPattern.FindStringSubmatch(name); match != nil {
+		name = match[2]
+	}
+	name = strings.TrimSpace(strings.NewReplacer("-", " ", "_", " ").Replace(name))
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// escapeMenuLabel escapes characters of a label that would break the Markdown link
+func escapeMenuLabel(label string) string {
+	return strings.NewReplacer("[", "\\[", "]", "\\]").Replace(label)
+}
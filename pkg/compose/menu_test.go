@@ -0,0 +1,115 @@
+package compose
+
+// run: go test ./pkg/compose -run TestGeneratedMenu
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneratedMenu(t *testing.T) {
+
+	config, _ := getTestConfig(t,
+		*NewOrigin("https://github.com/snipem/monako.git", "master", "docs", "docs/monako"),
+		*NewOrigin("https://github.com/snipem/commute-tube.git", "master", ".", "docs/commute-tube/"),
+	)
+	config.GenerateMenu = true
+
+	addComposedTestFiles(t, &config.Origins[0], map[string]string{
+		"docs/README.md":                          "---\ntitle: Monako\n---\n# Monako",
+		"docs/usage.md":                           "# Usage",
+		"docs/faq.md":                             "---\nweight: 1\n---\n# FAQ",
+		"docs/02-advanced/README.md":              "# Advanced",
+		"docs/01-getting_started/02-config.md":    "---\ntitle: Configuration [YAML]\n---\n",
+		"docs/01-getting_started/01-install.adoc": "= Install",
+		"docs/logo.png":                           "PNG",
+	})
+	addComposedTestFiles(t, &config.Origins[1], map[string]string{
+		"index.md": "# Commute Tube",
+	})
+
+	t.Run("Menu tree", func(t *testing.T) {
+		tree, err := config.getMenuTree()
+		assert.NoError(t, err)
+
+		var menu strings.Builder
+		tree.render(&menu, 0)
+		assert.Equal(t, `- **Docs**
+  - [**Commute tube**]({{< relref "/docs/commute-tube/index.md" >}})
+  - [**Monako**]({{< relref "/docs/monako/README.md" >}})
+    - [Faq]({{< relref "/docs/monako/faq.md" >}})
+    - **Getting started**
+      - [Install]({{< relref "/docs/monako/01-getting_started/01-install.adoc" >}})
+      - [Configuration \[YAML\]]({{< relref "/docs/monako/01-getting_started/02-config.md" >}})
+    - [**Advanced**]({{< relref "/docs/monako/02-advanced/README.md" >}})
+    - [Usage]({{< relref "/docs/monako/usage.md" >}})
+`, menu.String())
+	})
+
+	t.Run("Handwritten header", func(t *testing.T) {
+		menuConfig := filepath.Join(config.HugoWorkingDir, "config.menu.md")
+		assert.NoError(t, ioutil.WriteFile(menuConfig, []byte("---\nheadless: true\n---\n- [Home](/)\n"), standardFilemode))
+		assert.NoError(t, createMenuConfig(config, menuConfig))
+		assert.NoError(t, config.writeGeneratedMenu())
+
+		menu, err := ioutil.ReadFile(filepath.Join(config.ContentWorkingDir, monakoMenuDirectory, "index.md"))
+		assert.NoError(t, err)
+		assert.Contains(t, string(menu), "---\nheadless: true\n---\n- [Home](/)\n\n- **Docs**\n  - [**Commute tube**]")
+	})
+
+	t.Run("Without header", func(t *testing.T) {
+		assert.NoError(t, createMenuConfig(config, filepath.Join(config.HugoWorkingDir, "missing.md")))
+		assert.NoError(t, config.writeGeneratedMenu())
+
+		menu, err := ioutil.ReadFile(filepath.Join(config.ContentWorkingDir, monakoMenuDirectory, "index.md"))
+		assert.NoError(t, err)
+		assert.Contains(t, string(menu), menuFrontmatter+"\n- **Docs**\n  - [**Commute tube**]")
+
+		config.GenerateMenu = false
+		assert.NoError(t, config.writeComposedMenu(), "Menu config is kept")
+		assert.Error(t, createMenuConfig(config, filepath.Join(config.HugoWorkingDir, "missing.md")), "Menu config is needed without generated menu")
+	})
+}
+
+func TestGeneratedMenuNestedTargetDirs(t *testing.T) {
+
+	config, _ := getTestConfig(t,
+		*NewOrigin("https://github.com/snipem/handbook.git", "master", ".", "docs"),
+		*NewOrigin("https://github.com/snipem/monako.git", "master", "docs", "docs/monako"),
+	)
+	addComposedTestFiles(t, &config.Origins[0], map[string]string{"intro.md": "# Intro"})
+	addComposedTestFiles(t, &config.Origins[1], map[string]string{"docs/usage.md": "# Usage"})
+
+	tree, err := config.getMenuTree()
+	assert.NoError(t, err)
+
+	var menu strings.Builder
+	tree.render(&menu, 0)
+	assert.Equal(t, `- **Docs**
+  - [Intro]({{< relref "/docs/intro.md" >}})
+  - **Monako**
+    - [Usage]({{< relref "/docs/monako/usage.md" >}})
+`, menu.String(), "Target dirs below other target dirs are nested sections")
+	assert.Len(t, tree.findSection("docs").children, 2)
+}
+
+func TestPrettifyName(t *testing.T) {
+	assert.Equal(t, "Getting started", prettifyName("01-getting_started"))
+	assert.Equal(t, "Monako", prettifyName("monako"))
+	assert.Equal(t, "2020", prettifyName("2020"))
+}
+
+// addComposedTestFiles writes the files as composed files of the origin to the content dir
+func addComposedTestFiles(t *testing.T, origin *Origin, files map[string]string) {
+	for remotePath, content := range files {
+		file := origin.newFile(remotePath)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(file.LocalPath), standardFilemode))
+		assert.NoError(t, ioutil.WriteFile(file.LocalPath, []byte(content), standardFilemode))
+		origin.Files = append(origin.Files, file)
+	}
+}
@@ -0,0 +1,186 @@
+package compose
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MenuEntry is an entry of the menu of the config. It links to a document of an origin, an
+// external URL or inserts a generated section. Entries without link are headings of their entries.
+type MenuEntry struct {
+	// Title is the label of the entry, the title of the document or section if empty
+	Title string `yaml:"title,omitempty"`
+
+	// Origin is the src of the origin containing the document
+	Origin string `yaml:"origin,omitempty"`
+	// Path is the path of the document in the origin
+	Path string `yaml:"path,omitempty"`
+
+	// URL is an external link
+	URL string `yaml:"url,omitempty"`
+
+	// Section is a composed directory like a targetdir, whose generated menu is inserted
+	Section string `yaml:"section,omitempty"`
+
+	Entries []MenuEntry `yaml:"entries,omitempty"`
+}
+
+// writeConfiguredMenu validates the menu of the config against the composed documents and
+// writes it to the menu bundle
+func (config *Config) writeConfiguredMenu() error {
+
+	tree, err := config.getMenuTree()
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error generating menu"))
+	}
+
+	root, problems := config.getConfiguredMenu(config.Menu, tree)
+	if len(problems) > 0 {
+		return fmt.Errorf("Invalid menu in config:\n%s", strings.Join(problems, "\n"))
+	}
+
+	var menu strings.Builder
+	menu.WriteString(menuFrontmatter)
+	menu.WriteString("\n")
+	sources := make([]string, strings.Count(menu.String(), "\n"))
+	root.render(&menu, 0)
+
+	err = config.writeMenu(menu.String())
+	config.menuSources = append(sources, root.getSources("")...)
+	return err
+}
+
+// getConfiguredMenu returns the menu nodes of the entries and all problems of the entries
+func (config *Config) getConfiguredMenu(entries []MenuEntry, tree *menuNode) (*menuNode, []string) {
+
+	node := &menuNode{}
+	var problems []string
+
+	for _, entry := range entries {
+		child, err := config.getMenuEntryNode(entry, tree)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+
+		child.source = fmt.Sprintf("menu entry '%s' of the config", entry.getName())
+
+		children, childProblems := config.getConfiguredMenu(entry.Entries, tree)
+		problems = append(problems, childProblems...)
+		child.children = append(child.children, children.children...)
+
+		node.children = append(node.children, child)
+	}
+	return node, problems
+}
+
+// getMenuEntryNode returns the menu node of a single entry without its entries
+func (config *Config) getMenuEntryNode(entry MenuEntry, tree *menuNode) (*menuNode, error) {
+
+	targets := 0
+	for _, target := range []string{entry.Path, entry.URL, entry.Section} {
+		if target != "" {
+			targets++
+		}
+	}
+	if targets > 1 {
+		return nil, fmt.Errorf("Menu entry '%s' can only have one of path, url and section", entry.getName())
+	}
+
+	switch {
+	case entry.Path != "":
+		return config.getMenuDocumentNode(entry)
+
+	case entry.Origin != "":
+		return nil, fmt.Errorf("Menu entry '%s' has an origin without path", entry.getName())
+
+	case entry.URL != "":
+		if entry.Title == "" {
+			return nil, fmt.Errorf("Menu entry for %s needs a title", entry.URL)
+		}
+		return &menuNode{label: entry.Title, page: entry.URL, external: true}, nil
+
+	case entry.Section != "":
+		section := tree.findSection(entry.Section)
+		if section == nil {
+			return nil, fmt.Errorf("Menu entry '%s' has section %s without composed documents", entry.getName(), entry.Section)
+		}
+		node := *section
+		if entry.Title != "" {
+			node.label = entry.Title
+		}
+		node.children = append([]*menuNode{}, section.children...)
+		return &node, nil
+
+	default:
+		if entry.Title == "" {
+			return nil, fmt.Errorf("Menu entry without link needs a title")
+		}
+		return &menuNode{label: entry.Title, sections: map[string]*menuNode{}}, nil
+	}
+}
+
+// getMenuDocumentNode returns the menu node linking to the document of an entry
+func (config *Config) getMenuDocumentNode(entry MenuEntry) (*menuNode, error) {
+
+	if entry.Origin == "" {
+		return nil, fmt.Errorf("Menu entry '%s' has a path without origin", entry.getName())
+	}
+
+	file, originFound := config.findComposedFile(entry.Origin, strings.TrimPrefix(entry.Path, "/"))
+	if !originFound {
+		return nil, fmt.Errorf("Menu entry '%s' has unknown origin %s", entry.getName(), entry.Origin)
+	}
+	if file == nil {
+		return nil, fmt.Errorf("Menu entry '%s' links to %s which is not composed from %s", entry.getName(), entry.Path, entry.Origin)
+	}
+	if file.GetFormat() == "" {
+		// Hugo can only link to pages in the menu
+		return nil, fmt.Errorf("Menu entry '%s' links to %s which is no document", entry.getName(), entry.Path)
+	}
+
+	page, err := config.getContentPath(file.LocalPath)
+	if err != nil {
+		return nil, err
+	}
+
+	label := entry.Title
+	if label == "" {
+		label, err = getDocumentTitle(file.LocalPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &menuNode{label: label, page: page}, nil
+}
+
+// findComposedFile returns the composed file of the origins with the src and whether an origin
+// with the src exists. Versions of an origin are searched in the order of the config.
+func (config *Config) findComposedFile(src string, remotePath string) (*OriginFile, bool) {
+	originFound := false
+	for i := range config.Origins {
+		origin := &config.Origins[i]
+		if origin.URL != src {
+			continue
+		}
+		originFound = true
+		for j := range origin.Files {
+			if origin.Files[j].RemotePath == remotePath {
+				return &origin.Files[j], true
+			}
+		}
+	}
+	return nil, originFound
+}
+
+// getName returns a name of the entry for error messages
+func (entry MenuEntry) getName() string {
+	for _, name := range []string{entry.Title, entry.Path, entry.URL, entry.Section} {
+		if name != "" {
+			return name
+		}
+	}
+	return "without title"
+}
@@ -0,0 +1,91 @@
+package compose
+
+// run: go test ./pkg/compose -run TestConfiguredMenu
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestConfiguredMenu(t *testing.T) {
+
+	config, _ := getTestConfig(t,
+		*NewOrigin("https://github.com/snipem/monako.git", "master", "docs", "docs/monako"),
+		*NewOrigin("https://github.com/snipem/commute-tube.git", "master", ".", "docs/commute-tube"),
+	)
+
+	addComposedTestFiles(t, &config.Origins[0], map[string]string{
+		"docs/README.md":          "---\ntitle: Monako\n---\n# Monako",
+		"docs/usage.md":           "# Usage",
+		"docs/advanced/README.md": "# Advanced",
+		"docs/advanced/config.md": "# Config",
+		"docs/logo.png":           "PNG",
+	})
+	addComposedTestFiles(t, &config.Origins[1], map[string]string{
+		"README.md": "# Commute Tube",
+	})
+
+	err := yaml.Unmarshal([]byte(`
+menu:
+  - title: My Projects
+    entries:
+      - origin: https://github.com/snipem/monako.git
+        path: docs/README.md
+        entries:
+          - title: How to use it
+            origin: https://github.com/snipem/monako.git
+            path: /docs/usage.md
+      - title: Commute Tube
+        origin: https://github.com/snipem/commute-tube.git
+        path: README.md
+  - section: docs/monako/advanced
+  - title: GitHub
+    url: https://github.com/snipem
+`), config)
+	assert.NoError(t, err)
+
+	err = config.writeComposedMenu()
+	assert.NoError(t, err)
+
+	menu, err := ioutil.ReadFile(filepath.Join(config.ContentWorkingDir, monakoMenuDirectory, "index.md"))
+	assert.NoError(t, err)
+	assert.Equal(t, menuFrontmatter+`
+- **My Projects**
+  - [Monako]({{< relref "/docs/monako/README.md" >}})
+    - [How to use it]({{< relref "/docs/monako/usage.md" >}})
+  - [Commute Tube]({{< relref "/docs/commute-tube/README.md" >}})
+- [**Advanced**]({{< relref "/docs/monako/advanced/README.md" >}})
+  - [Config]({{< relref "/docs/monako/advanced/config.md" >}})
+- [GitHub](https://github.com/snipem)
+`, string(menu))
+
+	t.Run("Invalid entries", func(t *testing.T) {
+		config.Menu = []MenuEntry{
+			{Title: "Missing", Origin: "https://github.com/snipem/monako.git", Path: "docs/missing.md"},
+			{Title: "Logo", Origin: "https://github.com/snipem/monako.git", Path: "docs/logo.png"},
+			{Title: "Unknown", Origin: "https://example.com/unknown.git", Path: "README.md"},
+			{Section: "docs/unknown"},
+			{Title: "Both", URL: "https://example.com", Section: "docs/monako"},
+			{URL: "https://example.com"},
+			{Entries: []MenuEntry{{Path: "README.md"}}},
+		}
+
+		err := config.writeComposedMenu()
+		assert.EqualError(t, err, `Invalid menu in config:
+Menu entry 'Missing' links to docs/missing.md which is not composed from https://github.com/snipem/monako.git
+Menu entry 'Logo' links to docs/logo.png which is no document
+Menu entry 'Unknown' has unknown origin https://example.com/unknown.git
+Menu entry 'docs/unknown' has section docs/unknown without composed documents
+Menu entry 'Both' can only have one of path, url and section
+Menu entry for https://example.com needs a title
+Menu entry without link needs a title`)
+	})
+
+	t.Run("Menu config is not used", func(t *testing.T) {
+		assert.NoError(t, createMenuConfig(config, filepath.Join(config.HugoWorkingDir, "missing.md")))
+	})
+}
@@ -0,0 +1,260 @@
+package compose
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/snipem/monako/pkg/helpers"
+)
+
+// menuRefPattern matches the targets of ref and relref shortcodes like {{< relref "/docs/readme" >}}
+var menuRefPattern = regexp.MustCompile("\\{\\{[<%]\\s*((?:rel)?ref)\\s+(?:path=)?[\"`]([^\"`]*)[\"`]")
+
+// maxMenuRefSuggestions is the maximum number of near matches suggested for a missing reference
+const maxMenuRefSuggestions = 3
+
+// contentIndex contains the composed pages and directories of the content dir
+type contentIndex struct {
+	// pages are the slash separated paths of all documents relative to the content dir
+	pages []string
+	// paths are the lower case paths of the documents with and without extension and of their directories
+	paths map[string]bool
+	// names are the documents by their lower case file name with and without extension
+	names map[string][]string
+}
+
+// checkMenuRefs resolves the references of the composed menu against the composed documents and
+// returns an error listing all references to missing documents with suggestions. Unless composing
+// fails on errors, the problems are only logged as a warning.
+func (config *Config) checkMenuRefs() error {
+
+	menuFile := filepath.Join(config.ContentWorkingDir, monakoMenuDirectory, "index.md")
+	content, err := ioutil.ReadFile(menuFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error reading menu %s", menuFile))
+	}
+
+	index, err := newContentIndex(config.ContentWorkingDir)
+	if err != nil {
+		return err
+	}
+
+	name := config.menuConfigFile
+	if name == "" {
+		name = menuFile
+	}
+
+	problems := index.findMenuRefProblems(name, string(content), config.menuSources)
+	if len(problems) == 0 {
+		return nil
+	}
+
+	err = fmt.Errorf("Found %d broken references in menu:\n%s", len(problems), strings.Join(problems, "\n"))
+	if !config.failOnError {
+		log.Warn(err)
+		return nil
+	}
+	return err
+}
+
+// findMenuRefProblems returns a description of every reference of the menu that can't be resolved.
+// Problems name the line of the menu or the source of the line, if it is not taken from the file.
+func (index *contentIndex) findMenuRefProblems(name string, content string, sources []string) []string {
+
+	var problems []string
+	for i, line := range strings.Split(content, "\n") {
+		for _, match := range menuRefPattern.FindAllStringSubmatch(line, -1) {
+			shortcode, ref := match[1], match[2]
+
+			matches := index.resolve(ref)
+			if len(matches) == 1 {
+				continue
+			}
+
+			location := fmt.Sprintf("%s:%d", name, i+1)
+			if i < len(sources) && sources[i] != "" {
+				location = sources[i]
+			}
+			problem := fmt.Sprintf("%s: %s \"%s\"", location, shortcode, ref)
+			if len(matches) > 1 {
+				problem += fmt.Sprintf(" is ambiguous, it matches %s", strings.Join(matches, ", "))
+			} else {
+				problem += " points to a missing page"
+				if suggestions := index.suggest(ref); len(suggestions) > 0 {
+					problem += fmt.Sprintf(", did you mean %s?", strings.Join(suggestions, " or "))
+				}
+			}
+			problems = append(problems, problem)
+		}
+	}
+	return problems
+}
+
+// newContentIndex returns the index of all documents below the content dir except the menu
+func newContentIndex(contentDir string) (*contentIndex, error) {
+
+	index := &contentIndex{
+		paths: map[string]bool{},
+		names: map[string][]string{},
+	}
+
+	err := filepath.Walk(contentDir, func(localPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == monakoMenuDirectory {
+			return filepath.SkipDir
+		}
+		if info.IsDir() || !(helpers.IsMarkdown(localPath) || helpers.IsAsciidoc(localPath)) {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(contentDir, localPath)
+		if err != nil {
+			return err
+		}
+		page := filepath.ToSlash(relativePath)
+		index.pages = append(index.pages, page)
+
+		lower := strings.ToLower(page)
+		index.paths[lower] = true
+		index.paths[trimExt(lower)] = true
+		for dir := path.Dir(lower); dir != "."; dir = path.Dir(dir) {
+			index.paths[dir] = true
+		}
+
+		for _, name := range []string{path.Base(lower), trimExt(path.Base(lower))} {
+			index.names[name] = append(index.names[name], "/"+page)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error indexing content dir %s", contentDir))
+	}
+
+	sort.Strings(index.pages)
+	return index, nil
+}
+
+// resolve returns the pages a reference of the menu points to like Hugo does. Absolute references
+// start at the content dir, others are relative to the menu or the file name of a page.
+func (index *contentIndex) resolve(ref string) []string {
+
+	if i := strings.Index(ref, "#"); i >= 0 {
+		ref = ref[:i]
+	}
+	ref = strings.ToLower(strings.TrimSuffix(ref, "/"))
+	if ref == "" {
+		// References to anchors of the menu itself
+		return []string{ref}
+	}
+
+	if strings.HasPrefix(ref, "/") {
+		if index.paths[strings.TrimPrefix(ref, "/")] {
+			return []string{ref}
+		}
+		return nil
+	}
+
+	for _, candidate := range []string{path.Join(monakoMenuDirectory, ref), ref} {
+		if index.paths[candidate] {
+			return []string{candidate}
+		}
+	}
+
+	if !strings.Contains(ref, "/") {
+		return index.names[ref]
+	}
+	return nil
+}
+
+// suggest returns the pages with the paths or file names closest to the reference
+func (index *contentIndex) suggest(ref string) []string {
+
+	if i := strings.Index(ref, "#"); i >= 0 {
+		ref = ref[:i]
+	}
+	target := trimExt(strings.ToLower(strings.Trim(ref, "/")))
+	maxDistance := len(path.Base(target)) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+
+	type suggestion struct {
+		page string
+		// distance is the smaller distance of the paths and the file names
+		distance int
+		// pathDistance is the distance of the paths, which orders pages with the same file name
+		pathDistance int
+	}
+	var suggestions []suggestion
+	for _, page := range index.pages {
+		candidate := trimExt(strings.ToLower(page))
+		pathDistance := levenshtein(target, candidate)
+		distance := minInt(pathDistance, levenshtein(path.Base(target), path.Base(candidate)))
+		if distance <= maxDistance {
+			suggestions = append(suggestions, suggestion{page: "/" + page, distance: distance, pathDistance: pathDistance})
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].distance != suggestions[j].distance {
+			return suggestions[i].distance < suggestions[j].distance
+		}
+		return suggestions[i].pathDistance < suggestions[j].pathDistance
+	})
+
+	var pages []string
+	for i := 0; i < len(suggestions) && i < maxMenuRefSuggestions; i++ {
+		pages = append(pages, suggestions[i].page)
+	}
+	return pages
+}
+
+// trimExt returns the path without its extension
+func trimExt(p string) string {
+	return strings.TrimSuffix(p, path.Ext(p))
+}
+
+// levenshtein returns the edit distance of two strings
+func levenshtein(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current := make([]int, len(rb)+1)
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(rb)]
+}
+
+// minInt returns the smallest of the numbers
+func minInt(first int, others ...int) int {
+	for _, other := range others {
+		if other < first {
+			first = other
+		}
+	}
+	return first
+}
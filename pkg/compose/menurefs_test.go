@@ -0,0 +1,108 @@
+package compose
+
+// run: go test ./pkg/compose -run TestCheckMenuRefs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckMenuRefs(t *testing.T) {
+
+	config, _ := getTestConfig(t,
+		*NewOrigin("https://github.com/snipem/monako.git", "master", "docs", "docs/monako"),
+		*NewOrigin("https://github.com/snipem/commute-tube.git", "master", ".", "docs/commute-tube"),
+	)
+	addComposedTestFiles(t, &config.Origins[0], map[string]string{
+		"docs/README.md":             "# Monako",
+		"docs/getting-started.md":    "# Getting started",
+		"docs/advanced/config.adoc":  "= Config",
+		"docs/advanced/internals.md": "# Internals",
+	})
+	addComposedTestFiles(t, &config.Origins[1], map[string]string{
+		"README.md": "# Commute Tube",
+	})
+
+	menuConfig := filepath.Join(config.HugoWorkingDir, "config.menu.md")
+	assert.NoError(t, ioutil.WriteFile(menuConfig, []byte(`---
+headless: true
+---
+- [Monako]({{< relref "/docs/monako/README.md" >}})
+- [Monako]({{< relref "/docs/monako/readme" >}})
+- [Start]({{<relref "getting-started.md#install">}})
+- [Config]({{% ref "docs/monako/advanced/config" %}})
+- [Advanced]({{< relref "/docs/monako/advanced/" >}})
+- [Commute]({{< relref "/docs/commute/readme" >}})
+- [Readme]({{< relref "README.md" >}})
+- [Started]({{< relref "/docs/monako/geting-started.md" >}})
+- [Nothing]({{< relref "/completely/different.md" >}})
+`), standardFilemode))
+	assert.NoError(t, createMenuConfig(config, menuConfig))
+
+	assert.NoError(t, config.checkMenuRefs(), "Broken references are only warnings by default")
+
+	config.failOnError = true
+	err := config.checkMenuRefs()
+	assert.EqualError(t, err, "Found 4 broken references in menu:\n"+
+		menuConfig+`:9: relref "/docs/commute/readme" points to a missing page, did you mean /docs/commute-tube/README.md or /docs/monako/README.md?`+"\n"+
+		menuConfig+`:10: relref "README.md" is ambiguous, it matches /docs/commute-tube/README.md, /docs/monako/README.md`+"\n"+
+		menuConfig+`:11: relref "/docs/monako/geting-started.md" points to a missing page, did you mean /docs/monako/getting-started.md?`+"\n"+
+		menuConfig+`:12: relref "/completely/different.md" points to a missing page`)
+
+	t.Run("Without menu", func(t *testing.T) {
+		config, _ := getTestConfig(t)
+		assert.NoError(t, config.checkMenuRefs())
+	})
+}
+
+func TestCheckMenuRefsSources(t *testing.T) {
+
+	config, _ := getTestConfig(t, *NewOrigin("https://github.com/snipem/monako.git", "master", "docs", "docs/monako"))
+	config.failOnError = true
+	addComposedTestFiles(t, &config.Origins[0], map[string]string{
+		"docs/README.md": "# Monako",
+		"docs/usage.md":  "# Usage",
+	})
+	usage := filepath.Join(config.ContentWorkingDir, "docs", "monako", "usage.md")
+
+	t.Run("Menu of the config", func(t *testing.T) {
+		config.Menu = []MenuEntry{{Title: "How to use it", Origin: "https://github.com/snipem/monako.git", Path: "docs/usage.md"}}
+		defer func() { config.Menu = nil }()
+		assert.NoError(t, config.writeComposedMenu())
+
+		// Hugo disagrees with the composed documents
+		assert.NoError(t, os.Rename(usage, usage+".bak"))
+		defer func() { assert.NoError(t, os.Rename(usage+".bak", usage)) }()
+
+		err := config.checkMenuRefs()
+		assert.EqualError(t, err, "Found 1 broken references in menu:\n"+
+			`menu entry 'How to use it' of the config: relref "/docs/monako/usage.md" points to a missing page`)
+	})
+
+	t.Run("Generated menu", func(t *testing.T) {
+		config.GenerateMenu = true
+		menuConfig := filepath.Join(config.HugoWorkingDir, "config.menu.md")
+		assert.NoError(t, ioutil.WriteFile(menuConfig, []byte("---\nheadless: true\n---\n- [Old]({{< relref \"/docs/old.md\" >}})\n"), standardFilemode))
+		assert.NoError(t, createMenuConfig(config, menuConfig))
+		assert.NoError(t, config.writeComposedMenu())
+
+		assert.NoError(t, os.Rename(usage, usage+".bak"))
+		defer func() { assert.NoError(t, os.Rename(usage+".bak", usage)) }()
+
+		err := config.checkMenuRefs()
+		assert.EqualError(t, err, "Found 2 broken references in menu:\n"+
+			menuConfig+`:4: relref "/docs/old.md" points to a missing page`+"\n"+
+			`generated menu entry 'Usage': relref "/docs/monako/usage.md" points to a missing page`)
+	})
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, levenshtein("readme", "readme"))
+	assert.Equal(t, 1, levenshtein("geting", "getting"))
+	assert.Equal(t, 3, levenshtein("kitten", "sitting"))
+	assert.Equal(t, 4, levenshtein("", "test"))
+}
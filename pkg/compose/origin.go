@@ -3,20 +3,27 @@ package compose
 // run: make test
 
 import (
+	"crypto/sha1"
 	"fmt"
+	"io"
 	"os"
 	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
 
-	"github.com/gohugoio/hugo/hugofs/files"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/snipem/monako/pkg/helpers"
 	"gopkg.in/src-d/go-billy.v4"
 	"gopkg.in/src-d/go-billy.v4/memfs"
+	"gopkg.in/src-d/go-billy.v4/osfs"
 	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
 	"gopkg.in/src-d/go-git.v4/plumbing"
-	"gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+	"gopkg.in/src-d/go-git.v4/plumbing/cache"
+	gitfs "gopkg.in/src-d/go-git.v4/storage/filesystem"
 	"gopkg.in/src-d/go-git.v4/storage/memory"
 )
 
@@ -26,80 +33,513 @@ const Asciidoc = "ASCIIDOC"
 // Markdown is a const for identifying Markdown Documents
 const Markdown = "MARKDOWN"
 
-// CloneDir clones a HTTPS or lokal Git repository with the given branch and optional username and password.
-// A virtual filesystem is returned containing the cloned files.
+// OriginTypeGit is the type of origins that are cloned from a Git repository
+const OriginTypeGit = "git"
+
+// openFilesystem returns the filesystem containing the files of the origin depending on its type
+func (origin *Origin) openFilesystem() (billy.Filesystem, error) {
+	switch origin.Type {
+	case "", OriginTypeGit:
+		filesystem, err := origin.CloneDir()
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error cloning origin %s", origin.URL))
+		}
+		return filesystem, nil
+	case OriginTypeLocal:
+		filesystem, err := origin.OpenLocalDir()
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error opening local origin %s", origin.URL))
+		}
+		return filesystem, nil
+	case OriginTypeArchive:
+		filesystem, err := origin.OpenArchive()
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error opening archive origin %s", origin.URL))
+		}
+		return filesystem, nil
+	default:
+		return nil, fmt.Errorf("Unknown type '%s' of origin %s", origin.Type, origin.URL)
+	}
+}
+
+// isGit returns true if the origin is a Git repository
+func (origin *Origin) isGit() bool {
+	return origin.Type == "" || origin.Type == OriginTypeGit
+}
+
+// CloneDir clones a HTTPS or lokal Git repository with the given branch, tag or commit and optional username and password.
+// A virtual filesystem is returned containing the cloned files. If a cache dir is configured, the repository
+// is stored on disk instead and only new objects are fetched on subsequent runs.
 func (origin *Origin) CloneDir() (filesystem billy.Filesystem, err error) {
 
-	fmt.Printf("\nCloning in to '%s' with branch '%s' ...\n", origin.URL, origin.Branch)
-	log.Debugf("Start cloning of %s", origin.URL)
+	origin.printf("\nCloning in to '%s' with %s ...\n", origin.URL, origin.describeRevision())
+	origin.getLogger().Debugf("Start cloning of %s", origin.URL)
+
+	err = origin.validateRevision()
+	if err != nil {
+		return nil, err
+	}
+
+	if origin.config.CacheDir != "" {
+		return origin.cloneCachedDir()
+	}
+
+	cloneOptions, err := origin.getCloneOptions()
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error creating clone options for %s", origin.URL))
+	}
 
 	filesystem = memfs.New()
 
-	basicauth := http.BasicAuth{}
+	repo, err := git.Clone(memory.NewStorage(), filesystem, cloneOptions)
 
-	username := os.Getenv(origin.EnvUsername)
-	password := os.Getenv(origin.EnvPassword)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error while cloning into %s", origin.URL))
+	}
 
-	if username != "" && password != "" {
-		fmt.Printf("Using username and password stored in env variables\n")
-		basicauth = http.BasicAuth{
-			Username: username,
-			Password: password,
+	if origin.Commit != "" {
+		err = origin.checkoutCommit(repo)
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	depth := 0
+	err = origin.updateSubmodules(repo)
+	if err != nil {
+		return nil, err
+	}
 
-	if origin.config.DisableCommitInfo {
-		// problem with depth = 1 is that git log from older commits, can't be accessed
-		// since CommitInfo is disabled anyway, use depth = 1 for speed boost
-		depth = 1
+	err = origin.resolveHead(repo)
+	if err != nil {
+		return nil, err
 	}
 
-	repo, err := git.Clone(memory.NewStorage(), filesystem, &git.CloneOptions{
-		URL:           origin.URL,
-		Depth:         depth,
-		ReferenceName: plumbing.ReferenceName(fmt.Sprintf("refs/heads/%s", origin.Branch)),
-		SingleBranch:  true,
-		Auth:          &basicauth,
-	})
+	origin.repo = repo
+	origin.getLogger().Debugf("Ended cloning of %s", origin.URL)
+
+	return filesystem, nil
 
+}
+
+// cloneCachedDir clones the origin into its directory below the cache dir. If the repository is
+// already present in the cache, only the configured revision is fetched and checked out.
+func (origin *Origin) cloneCachedDir() (filesystem billy.Filesystem, err error) {
+
+	cacheDir := origin.getCacheDir()
+	filesystem = osfs.New(filepath.Join(cacheDir, "worktree"))
+	storage := gitfs.NewStorage(osfs.New(filepath.Join(cacheDir, "repo.git")), cache.NewObjectLRUDefault())
+
+	repo, err := git.Open(storage, filesystem)
+
+	if err == git.ErrRepositoryNotExists {
+		origin.printf("No cached repository found in '%s', cloning ...\n", cacheDir)
+		var cloneOptions *git.CloneOptions
+		cloneOptions, err = origin.getCloneOptions()
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error creating clone options for %s", origin.URL))
+		}
+		repo, err = git.Clone(storage, filesystem, cloneOptions)
+		if err != nil {
+			// Don't leave a half cloned repository in the cache
+			_ = os.RemoveAll(cacheDir)
+			return nil, errors.Wrap(err, fmt.Sprintf("Error while cloning into cache %s", cacheDir))
+		}
+		if origin.Commit != "" {
+			err = origin.checkoutCommit(repo)
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error while opening cached repository %s", cacheDir))
+	} else {
+		origin.printf("Using cached repository in '%s', fetching ...\n", cacheDir)
+		err = origin.updateCachedRepo(repo)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Error while updating cached repository %s", cacheDir))
+		}
+	}
+
+	err = origin.updateSubmodules(repo)
 	if err != nil {
-		return nil, errors.Wrap(err, fmt.Sprintf("Error while cloning into %s", origin.URL))
+		return nil, err
+	}
+
+	err = origin.resolveHead(repo)
+	if err != nil {
+		return nil, err
 	}
 
 	origin.repo = repo
-	log.Debugf("Ended cloning of %s", origin.URL)
+	origin.getLogger().Debugf("Ended cloning of %s", origin.URL)
 
 	return filesystem, nil
+}
+
+// updateCachedRepo fetches the configured revision of an already cached repository and
+// resets the worktree to the fetched commit
+func (origin *Origin) updateCachedRepo(repo *git.Repository) error {
+
+	refSpec, fetchedRef := origin.getFetchRefSpec()
+
+	auth, err := origin.getAuth()
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error creating authentication for %s", origin.URL))
+	}
+
+	err = repo.Fetch(&git.FetchOptions{
+		RemoteName: git.DefaultRemoteName,
+		RefSpecs:   []config.RefSpec{refSpec},
+		Depth:      origin.getDepth(),
+		Auth:       auth,
+		Force:      true,
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return errors.Wrap(err, fmt.Sprintf("Error while fetching %s", origin.URL))
+	}
+
+	revision := plumbing.Revision(fetchedRef)
+	if origin.Commit != "" {
+		revision = plumbing.Revision(origin.Commit)
+	}
+
+	hash, err := repo.ResolveRevision(revision)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error resolving fetched revision %s", revision))
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error opening worktree of cached repository"))
+	}
+
+	err = worktree.Reset(&git.ResetOptions{
+		Commit: *hash,
+		Mode:   git.HardReset,
+	})
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error resetting worktree to %s", hash))
+	}
+
+	origin.getLogger().Debugf("Cached repository of %s is at %s", origin.URL, hash)
+	return nil
+}
 
+// checkoutCommit checks out the configured commit of a freshly cloned repository
+func (origin *Origin) checkoutCommit(repo *git.Repository) error {
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error opening worktree of %s", origin.URL))
+	}
+
+	err = worktree.Checkout(&git.CheckoutOptions{
+		Hash:  plumbing.NewHash(origin.Commit),
+		Force: true,
+	})
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error checking out commit %s of %s", origin.Commit, origin.URL))
+	}
+	return nil
+}
+
+// resolveHead stores the commit and branch the checked out HEAD of the repository points to
+func (origin *Origin) resolveHead(repo *git.Repository) error {
+
+	head, err := repo.Head()
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error resolving HEAD of %s", origin.URL))
+	}
+
+	origin.resolvedCommit = head.Hash().String()
+	if head.Name().IsBranch() {
+		origin.resolvedBranch = head.Name().Short()
+	}
+
+	origin.printf("Resolved %s to commit %s\n", origin.describeRevision(), origin.resolvedCommit)
+	return nil
+}
+
+// validateRevision checks that at most one of tag and commit is set and that commits are full SHAs
+func (origin *Origin) validateRevision() error {
+
+	if origin.Tag != "" && origin.Commit != "" {
+		return fmt.Errorf("Origin %s can't have a tag and a commit", origin.URL)
+	}
+
+	if origin.Tag != "" && origin.Branch != "" {
+		return fmt.Errorf("Origin %s can't have a tag and a branch", origin.URL)
+	}
+
+	if origin.Commit != "" && !commitSHA.MatchString(origin.Commit) {
+		return fmt.Errorf("Commit '%s' of origin %s is not a full 40 character SHA", origin.Commit, origin.URL)
+	}
+
+	return nil
+}
+
+// commitSHA matches a full Git commit SHA
+var commitSHA = regexp.MustCompile("^[0-9a-f]{40}$")
+
+// describeRevision returns a human readable description of the configured revision
+func (origin *Origin) describeRevision() string {
+	switch {
+	case origin.Commit != "":
+		return fmt.Sprintf("commit '%s'", origin.Commit)
+	case origin.Tag != "":
+		return fmt.Sprintf("tag '%s'", origin.Tag)
+	case origin.Branch != "":
+		return fmt.Sprintf("branch '%s'", origin.Branch)
+	default:
+		return "the default branch"
+	}
+}
+
+// getWebRef returns the branch, tag or commit that is used for links to the origin's web interface
+func (origin *Origin) getWebRef() string {
+	switch {
+	case origin.Commit != "":
+		return origin.Commit
+	case origin.Tag != "":
+		return origin.Tag
+	case origin.Branch != "":
+		return origin.Branch
+	case origin.resolvedBranch != "":
+		return origin.resolvedBranch
+	default:
+		return origin.resolvedCommit
+	}
+}
+
+// getCacheDir returns the directory in the cache dir that is used for this origin.
+// It is unique per repository URL and branch or tag.
+func (origin *Origin) getCacheDir() string {
+	key := sha1.Sum([]byte(origin.URL + "#" + string(origin.getReferenceName())))
+	name := strings.TrimSuffix(path.Base(filepath.ToSlash(origin.URL)), ".git")
+	return filepath.Join(origin.config.CacheDir, fmt.Sprintf("%s-%x", name, key[:8]))
+}
+
+// getCloneOptions returns the options used for cloning this origin
+func (origin *Origin) getCloneOptions() (*git.CloneOptions, error) {
+
+	auth, err := origin.getAuth()
+	if err != nil {
+		return nil, err
+	}
+
+	return &git.CloneOptions{
+		URL:           origin.URL,
+		Depth:         origin.getDepth(),
+		ReferenceName: origin.getReferenceName(),
+		// A commit can only be found on any branch, if no branch is given
+		SingleBranch: origin.Commit == "" || origin.Branch != "",
+		NoCheckout:   origin.Commit != "",
+		Auth:         auth,
+	}, nil
+}
+
+// getReferenceName returns the Git reference of the configured tag or branch. If none of
+// them is configured, HEAD is used which points to the default branch of the remote
+func (origin *Origin) getReferenceName() plumbing.ReferenceName {
+	switch {
+	case origin.Tag != "":
+		return plumbing.NewTagReferenceName(origin.Tag)
+	case origin.Branch != "":
+		return plumbing.NewBranchReferenceName(origin.Branch)
+	default:
+		return plumbing.HEAD
+	}
+}
+
+// getFetchRefSpec returns the refspec for fetching the configured revision into a cached
+// repository and the local reference the fetched revision is stored in
+func (origin *Origin) getFetchRefSpec() (config.RefSpec, plumbing.ReferenceName) {
+	switch {
+	case origin.Tag != "":
+		ref := plumbing.NewTagReferenceName(origin.Tag)
+		return config.RefSpec(fmt.Sprintf("+%s:%s", ref, ref)), ref
+	case origin.Branch != "":
+		ref := plumbing.NewRemoteReferenceName(git.DefaultRemoteName, origin.Branch)
+		return config.RefSpec(fmt.Sprintf("+%s:%s", plumbing.NewBranchReferenceName(origin.Branch), ref)), ref
+	case origin.Commit != "":
+		// Fetch all branches, since the commit can be on any of them
+		return config.RefSpec(fmt.Sprintf(config.DefaultFetchRefSpec, git.DefaultRemoteName)), plumbing.HEAD
+	default:
+		ref := plumbing.NewRemoteReferenceName(git.DefaultRemoteName, "HEAD")
+		return config.RefSpec(fmt.Sprintf("+HEAD:%s", ref)), ref
+	}
+}
+
+// getDepth returns the clone depth, 0 means the whole history
+func (origin *Origin) getDepth() int {
+	if origin.config.DisableCommitInfo && origin.Commit == "" {
+		// A commit has to be searched in the whole history
+		// problem with depth = 1 is that git log from older commits, can't be accessed
+		// since CommitInfo is disabled anyway, use depth = 1 for speed boost
+		return 1
+	}
+	return 0
 }
 
 // Origin contains all information for a document origin
 type Origin struct {
-	URL           string   `yaml:"src"`
-	Branch        string   `yaml:"branch,omitempty"`
-	EnvUsername   string   `yaml:"envusername,omitempty"`
-	EnvPassword   string   `yaml:"envpassword,omitempty"`
+	// Type is the kind of the origin, a Git repository if empty
+	Type   string `yaml:"type,omitempty"`
+	URL    string `yaml:"src"`
+	Branch string `yaml:"branch,omitempty"`
+	// Tag pins the origin to a Git tag instead of a branch
+	Tag string `yaml:"tag,omitempty"`
+	// Commit pins the origin to a full commit SHA, optionally searched on the given branch
+	Commit string `yaml:"commit,omitempty"`
+
+	// Versions are branches or tags that are composed side by side below the target dir
+	Versions []string `yaml:"versions,omitempty"`
+	// VersionPattern selects all tags matching the glob pattern as versions, e.g. "v*"
+	VersionPattern string `yaml:"versionpattern,omitempty"`
+	// LatestVersion overrides the newest version which is also served below "latest"
+	LatestVersion string `yaml:"latestversion,omitempty"`
+
+	EnvUsername string `yaml:"envusername,omitempty"`
+	EnvPassword string `yaml:"envpassword,omitempty"`
+
+	// SSHKey is the path to a private key file used for SSH origins
+	SSHKey string `yaml:"sshkey,omitempty"`
+	// EnvSSHKey is the env variable containing a private key used for SSH origins
+	EnvSSHKey string `yaml:"envsshkey,omitempty"`
+	// EnvSSHPassphrase is the env variable containing the passphrase of the private key
+	EnvSSHPassphrase string `yaml:"envsshpassphrase,omitempty"`
+	// KnownHosts is the path to the known_hosts file used for verifying SSH hosts
+	KnownHosts string `yaml:"knownhosts,omitempty"`
+	// InsecureIgnoreHostKey disables the verification of SSH host keys
+	InsecureIgnoreHostKey bool `yaml:"insecureignorehostkey,omitempty"`
+
+	// Checksum is the SHA-256 checksum of an archive origin, optionally prefixed with "sha256:"
+	Checksum string `yaml:"checksum,omitempty"`
+	// MaxArchiveSize is the maximum size in bytes of an archive origin and of its extracted files
+	MaxArchiveSize int64 `yaml:"maxarchivesize,omitempty"`
+
+	// Submodules clones the submodules of the origin recursively
+	Submodules bool `yaml:"submodules,omitempty"`
+
+	// Forge is the type of the forge hosting the origin for links to its web interface, guessed from the URL if empty
+	Forge string `yaml:"forge,omitempty"`
+	// ForgeTemplates replace the link templates of the forge
+	ForgeTemplates ForgeTemplates `yaml:"forgetemplates,omitempty"`
+	// DisableEditLinks removes the links for editing the documents of the origin
+	DisableEditLinks bool `yaml:"disableeditlinks,omitempty"`
+
 	SourceDir     string   `yaml:"docdir,omitempty"`
 	TargetDir     string   `yaml:"targetdir,omitempty"`
 	FileWhitelist []string `yaml:"whitelist,omitempty"`
 	FileBlacklist []string `yaml:"blacklist,omitempty"`
 
+	// Title is the title of the section of the target dir
+	Title string `yaml:"title,omitempty"`
+	// PromoteIndex makes index and README documents the section index of their directory
+	PromoteIndex bool `yaml:"promoteindex,omitempty"`
+
+	// DisableDerivedTitle keeps documents without title in their frontmatter untitled instead of
+	// using their first heading
+	DisableDerivedTitle bool `yaml:"disablederivedtitle,omitempty"`
+	// DisableDerivedDescription keeps documents without description in their frontmatter without
+	// description instead of using their first paragraph
+	DisableDerivedDescription bool `yaml:"disablederiveddescription,omitempty"`
+
+	// ReferencedAssets copies exactly the files referenced by the documents instead of the
+	// whitelisted assets, the whitelist only selects the documents
+	ReferencedAssets bool `yaml:"referencedassets,omitempty"`
+
 	Files []OriginFile
 
 	repo   *git.Repository
 	config *Config
+
+	// resolvedCommit is the commit the checked out revision points to
+	resolvedCommit string
+	// resolvedBranch is the branch the checked out revision points to, empty for tags and commits
+	resolvedBranch string
+
+	// submodules are the checked out submodules, innermost first
+	submodules []*originSubmodule
+
+	// composedFiles are the files of the origin by their remote path
+	composedFiles map[string]*OriginFile
+
+	// ignore contains the ignore rules of the origin repository
+	ignore *originIgnore
+
+	// repoDir is the path of a local origin below the root of its enclosing Git repository
+	repoDir string
+
+	// version is set if this origin is a single version of a versioned origin
+	version *originVersion
+
+	// out is where the output of this origin is written to, standard output if nil
+	out io.Writer
+	// logger writes the log of this origin to out, the standard logger is used if nil
+	logger *log.Logger
+}
+
+// printf writes formatted output of this origin. When origins are composed concurrently,
+// the output is buffered per origin to keep it readable.
+func (origin *Origin) printf(format string, a ...interface{}) {
+	out := origin.out
+	if out == nil {
+		out = os.Stdout
+	}
+	fmt.Fprintf(out, format, a...)
+}
+
+// setOutput writes the output and the log of this origin to the writer
+func (origin *Origin) setOutput(out io.Writer) {
+	logger := log.New()
+	logger.SetOutput(out)
+	logger.SetLevel(log.GetLevel())
+	logger.SetFormatter(log.StandardLogger().Formatter)
+	origin.out = out
+	origin.logger = logger
+}
+
+// getLogger returns the logger of this origin
+func (origin *Origin) getLogger() *log.Logger {
+	if origin == nil || origin.logger == nil {
+		return log.StandardLogger()
+	}
+	return origin.logger
 }
 
 // ComposeDir copies a subdir of a virtual filesystem to a target in the local relative filesystem.
 // The copied files can be limited by a whitelist. The Git repository is used to obtain Git commit
 // information
 func (origin *Origin) ComposeDir(filesystem billy.Filesystem) error {
+	err := origin.loadIgnoreRules(filesystem)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error loading ignore rules of %s", origin.URL))
+	}
+
 	origin.Files = origin.getMatchingFiles(origin.SourceDir, filesystem)
 
+	if origin.ReferencedAssets {
+		origin.Files, err = origin.addReferencedAssets(origin.Files, filesystem)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error finding referenced assets of %s", origin.URL))
+		}
+	}
+
+	origin.promoteIndexDocuments()
+
+	origin.composedFiles = map[string]*OriginFile{}
+	for i := range origin.Files {
+		origin.composedFiles[origin.Files[i].RemotePath] = &origin.Files[i]
+	}
+
+	err = origin.addCommitInfo()
+	if err != nil {
+		return err
+	}
+
 	if len(origin.Files) == 0 {
-		log.Printf("Found no matching files in '%s' with branch '%s' in folder '%s'\n", origin.URL, origin.Branch, origin.SourceDir)
+		origin.getLogger().Printf("Found no matching files in '%s' with %s in folder '%s'\n", origin.URL, origin.describeRevision(), origin.SourceDir)
 	}
 
 	for _, file := range origin.Files {
@@ -125,6 +565,11 @@ func (origin *Origin) getMatchingFiles(startdir string, filesystem billy.Filesys
 
 	var originFiles []OriginFile
 
+	err := origin.readDirAttributes(filesystem, startdir)
+	if err != nil {
+		origin.getLogger().Warnf("Can't read attributes of '%s': %s", startdir, err)
+	}
+
 	files, _ := filesystem.ReadDir(startdir)
 	for _, file := range files {
 
@@ -134,7 +579,16 @@ func (origin *Origin) getMatchingFiles(startdir string, filesystem billy.Filesys
 		// Use path here to support unixoid Git paths
 		remotePath := path.Join(startdir, file.Name())
 
+		if origin.isIgnored(remotePath, file.IsDir()) {
+			continue
+		}
+
 		if file.IsDir() {
+			// Excluded trees like node_modules are never walked
+			if helpers.DirIsPruned(remotePath, origin.FileBlacklist) {
+				origin.getLogger().Debugf("Skipping excluded directory '%s'", remotePath)
+				continue
+			}
 			// Recurse over file and add their files to originFiles
 			originFiles = append(
 				originFiles,
@@ -142,8 +596,8 @@ func (origin *Origin) getMatchingFiles(startdir string, filesystem billy.Filesys
 					remotePath,
 					filesystem,
 				)...)
-		} else if helpers.FileIsListed(file.Name(), origin.FileWhitelist) &&
-			!helpers.FileIsListed(file.Name(), origin.FileBlacklist) {
+		} else if helpers.PathIsListed(remotePath, origin.FileWhitelist) &&
+			!helpers.PathIsListed(remotePath, origin.FileBlacklist) {
 
 			// Add the current file to the list of files returned
 			originFiles = append(
@@ -158,29 +612,10 @@ func (origin *Origin) getMatchingFiles(startdir string, filesystem billy.Filesys
 func (origin *Origin) newFile(remotePath string) OriginFile {
 	localPath := getLocalFilePath(origin.config.ContentWorkingDir, origin.SourceDir, origin.TargetDir, remotePath)
 
-	originFile := OriginFile{
+	return OriginFile{
 		RemotePath: remotePath,
 		LocalPath:  localPath,
 
 		parentOrigin: origin,
 	}
-
-	if !origin.config.DisableCommitInfo {
-
-		// Only get commit info for content files
-		// This speeds up commit fetching on repository with lots of files
-		// heavily. Most non content files are static and therefore way back
-		// in the commit log. This also reduces the calls to git log.
-		if files.IsContentFile(remotePath) {
-			// TODO add safe way to acces not existing commit info
-			commitinfo, err := getCommitInfo(remotePath, origin.repo)
-			if err != nil {
-				log.Warnf("Can't extract Commit Info for '%s'", err)
-			}
-			originFile.Commit = commitinfo
-
-		}
-	}
-
-	return originFile
 }
@@ -3,13 +3,23 @@ package compose
 // run: MONAKO_TEST_REPO="/tmp/testdata/monako-test" go test ./pkg/compose/
 
 import (
+	"bytes"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/gohugoio/hugo/hugofs/files"
+	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-billy.v4"
+	"gopkg.in/src-d/go-billy.v4/memfs"
+	"gopkg.in/src-d/go-billy.v4/util"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -74,3 +84,227 @@ func TestGitCommiter(t *testing.T) {
 		})
 	}
 }
+
+func TestCloneDirWithCache(t *testing.T) {
+
+	repoDir := createTestRepository(t, map[string]string{
+		"README.md": "# Cached",
+	})
+
+	config, tempdir := getTestConfig(t, *NewOrigin(repoDir, "master", ".", "docs/cached"))
+	config.CacheDir = filepath.Join(tempdir, "cache")
+	origin := &config.Origins[0]
+
+	filesystem, err := origin.CloneDir()
+	assert.NoError(t, err)
+	assert.DirExists(t, origin.getCacheDir())
+
+	_, err = filesystem.Stat("README.md")
+	assert.NoError(t, err, "Cloned file is missing")
+
+	t.Run("Fetch new commits into cached repo", func(t *testing.T) {
+		commitTestFiles(t, repoDir, map[string]string{
+			"new.md": "# New",
+		})
+
+		filesystem, err := origin.CloneDir()
+		assert.NoError(t, err)
+
+		_, err = filesystem.Stat("new.md")
+		assert.NoError(t, err, "Fetched file is missing")
+
+		commit, err := getCommitInfo("new.md", origin.repo)
+		assert.NoError(t, err)
+		assert.Equal(t, "monako@example.com", commit.Author.Email)
+	})
+
+	t.Run("Cache dir is unique per branch", func(t *testing.T) {
+		other := NewOrigin(repoDir, "develop", ".", "docs/cached")
+		other.config = config
+		assert.NotEqual(t, origin.getCacheDir(), other.getCacheDir())
+	})
+}
+
+// createTestRepository creates a local Git repository with the given files committed
+// to the master branch and returns its path
+func createTestRepository(t *testing.T, files map[string]string) string {
+
+	return createTestRepositoryAt(t, filepath.Join(GetLocalTempDir(t), "repo"), files)
+}
+
+// commitTestFiles writes the given files to a local Git repository and commits them
+func commitTestFiles(t testing.TB, repoDir string, files map[string]string) plumbing.Hash {
+	return commitTestFilesAs(t, repoDir, object.Signature{Name: "Monako Test", Email: "monako@example.com"}, files)
+}
+
+// commitTestFilesAs writes the given files to a local Git repository and commits them as the
+// author. Without a date the current time is used.
+func commitTestFilesAs(t testing.TB, repoDir string, author object.Signature, files map[string]string) plumbing.Hash {
+
+	repo, err := git.PlainOpen(repoDir)
+	assert.NoError(t, err)
+	worktree, err := repo.Worktree()
+	assert.NoError(t, err)
+
+	for name, content := range files {
+		err = os.MkdirAll(filepath.Dir(filepath.Join(repoDir, name)), standardFilemode)
+		assert.NoError(t, err)
+		err = ioutil.WriteFile(filepath.Join(repoDir, name), []byte(content), standardFilemode)
+		assert.NoError(t, err)
+		_, err = worktree.Add(name)
+		assert.NoError(t, err)
+	}
+
+	if author.When.IsZero() {
+		author.When = time.Now()
+	}
+
+	hash, err := worktree.Commit("Add test files", &git.CommitOptions{
+		Author: &author,
+	})
+	assert.NoError(t, err)
+	return hash
+}
+
+func TestCloneDirRevisions(t *testing.T) {
+
+	repoDir := createTestRepository(t, map[string]string{
+		"release.md": "# Release",
+	})
+	repo, err := git.PlainOpen(repoDir)
+	assert.NoError(t, err)
+	head, err := repo.Head()
+	assert.NoError(t, err)
+	releaseCommit := head.Hash()
+
+	_, err = repo.CreateTag("v1.0.0", releaseCommit, nil)
+	assert.NoError(t, err)
+	_, err = repo.CreateTag("v1.0.0-annotated", releaseCommit, &git.CreateTagOptions{
+		Message: "Release",
+		Tagger:  &object.Signature{Name: "Monako Test", Email: "monako@example.com", When: time.Now()},
+	})
+	assert.NoError(t, err)
+
+	latestCommit := commitTestFiles(t, repoDir, map[string]string{
+		"unreleased.md": "# Unreleased",
+	})
+
+	cases := []struct {
+		name, branch, tag, commit string
+		wantCommit                plumbing.Hash
+		wantWebRef                string
+		wantUnreleased            bool
+	}{
+		{"Branch", "master", "", "", latestCommit, "master", true},
+		{"Default branch", "", "", "", latestCommit, "master", true},
+		{"Tag", "", "v1.0.0", "", releaseCommit, "v1.0.0", false},
+		{"Annotated tag", "", "v1.0.0-annotated", "", releaseCommit, "v1.0.0-annotated", false},
+		{"Commit", "", "", releaseCommit.String(), releaseCommit, releaseCommit.String(), false},
+		{"Commit on branch", "master", "", releaseCommit.String(), releaseCommit, releaseCommit.String(), false},
+	}
+
+	for _, cached := range []bool{false, true} {
+		for _, tc := range cases {
+			t.Run(fmt.Sprintf("%s cached %v", tc.name, cached), func(t *testing.T) {
+				origin := NewOrigin(repoDir, tc.branch, ".", "docs")
+				origin.Tag = tc.tag
+				origin.Commit = tc.commit
+				origin.config = &Config{}
+				if cached {
+					origin.config.CacheDir = filepath.Join(GetLocalTempDir(t), "cache")
+				}
+
+				// Second run uses the cached repository if cache is activated
+				for run := 0; run < 2; run++ {
+					filesystem, err := origin.CloneDir()
+					assert.NoError(t, err)
+
+					_, err = filesystem.Stat("release.md")
+					assert.NoError(t, err)
+					_, err = filesystem.Stat("unreleased.md")
+					assert.Equal(t, tc.wantUnreleased, err == nil, "Unreleased file present")
+
+					assert.Equal(t, tc.wantCommit.String(), origin.resolvedCommit)
+					assert.Equal(t, tc.wantWebRef, origin.getWebRef())
+				}
+			})
+		}
+	}
+
+	t.Run("Fail on invalid revisions", func(t *testing.T) {
+		for _, origin := range []*Origin{
+			{URL: repoDir, Tag: "v1.0.0", Commit: releaseCommit.String()},
+			{URL: repoDir, Tag: "v1.0.0", Branch: "master"},
+			{URL: repoDir, Commit: "abc123"},
+		} {
+			origin.config = &Config{}
+			_, err := origin.CloneDir()
+			assert.Error(t, err)
+		}
+	})
+}
+
+// recordingFilesystem records the directories that are read
+type recordingFilesystem struct {
+	billy.Filesystem
+	readDirs []string
+}
+
+// ReadDir records the directory and reads it from the wrapped filesystem
+func (fs *recordingFilesystem) ReadDir(path string) ([]os.FileInfo, error) {
+	fs.readDirs = append(fs.readDirs, path)
+	return fs.Filesystem.ReadDir(path)
+}
+
+func TestGetMatchingFilesWithPatterns(t *testing.T) {
+
+	filesystem := &recordingFilesystem{Filesystem: memfs.New()}
+	for _, name := range []string{
+		"docs/README.md",
+		"docs/guide/install.md",
+		"docs/guide/logo.png",
+		"docs/internal/secret.md",
+		"docs/internal/public.md",
+		"docs/node_modules/lib/README.md",
+		"docs/.github/ISSUE_TEMPLATE.md",
+		"docs/vendor/lib/README.md",
+	} {
+		assert.NoError(t, util.WriteFile(filesystem, name, []byte("# "+name), standardFilemode))
+	}
+
+	origin := NewOrigin("memory", "master", "docs", "docs/patterns")
+	origin.config = &Config{DisableCommitInfo: true}
+	origin.FileWhitelist = []string{"docs/**/*.md", ".png"}
+	origin.FileBlacklist = []string{"node_modules/", ".github/", "docs/vendor/**", "docs/internal/**", "!docs/internal/public.md"}
+
+	var remotePaths []string
+	for _, file := range origin.getMatchingFiles(origin.SourceDir, filesystem) {
+		remotePaths = append(remotePaths, file.RemotePath)
+	}
+
+	assert.ElementsMatch(t, []string{
+		"docs/README.md",
+		"docs/guide/install.md",
+		"docs/guide/logo.png",
+		"docs/internal/public.md",
+	}, remotePaths)
+
+	assert.NotContains(t, filesystem.readDirs, "docs/node_modules", "Excluded directories are pruned")
+	assert.NotContains(t, filesystem.readDirs, "docs/.github")
+	assert.NotContains(t, filesystem.readDirs, "docs/vendor")
+	assert.Contains(t, filesystem.readDirs, "docs/internal", "Negations below a directory keep it")
+}
+
+func TestOriginOutput(t *testing.T) {
+
+	var out bytes.Buffer
+	origin := NewOrigin("https://github.com/snipem/monako.git", "master", "docs", "docs/monako")
+	origin.setOutput(&out)
+
+	origin.printf("Composing %s\n", origin.URL)
+	origin.getLogger().Warnf("Can't read attributes of '%s'", "docs")
+	assert.Contains(t, out.String(), "Composing https://github.com/snipem/monako.git\n")
+	assert.Contains(t, out.String(), "Can't read attributes of 'docs'", "The log of the origin is buffered with its output")
+
+	assert.Equal(t, log.StandardLogger(), NewOrigin("", "", "", "").getLogger(), "Standard logger without output")
+}
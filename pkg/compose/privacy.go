@@ -0,0 +1,52 @@
+package compose
+
+import (
+	"crypto/md5"
+	"fmt"
+	"strings"
+)
+
+const (
+	// EmailPrivacyShow publishes author emails as they are
+	EmailPrivacyShow = "show"
+	// EmailPrivacyHide doesn't publish author emails
+	EmailPrivacyHide = "hide"
+	// EmailPrivacyHash publishes the MD5 hash of author emails, like used by Gravatar
+	EmailPrivacyHash = "hash"
+)
+
+// checkEmailPrivacy returns an error if the email privacy of the config is unknown
+func (config *Config) checkEmailPrivacy() error {
+	switch config.EmailPrivacy {
+	case "", EmailPrivacyShow, EmailPrivacyHide, EmailPrivacyHash:
+		return nil
+	default:
+		return fmt.Errorf("Unknown emailPrivacy '%s', use '%s', '%s' or '%s'",
+			config.EmailPrivacy, EmailPrivacyShow, EmailPrivacyHide, EmailPrivacyHash)
+	}
+}
+
+// getPublicEmail returns the email of an author as it is published in the frontmatter. Aliases
+// replace the email, all other emails are handled by the email privacy of the config. An empty
+// email is not published.
+func (config *Config) getPublicEmail(email string) string {
+
+	if config == nil || email == "" {
+		return email
+	}
+
+	for private, alias := range config.EmailAliases {
+		if strings.EqualFold(private, email) {
+			return alias
+		}
+	}
+
+	switch config.EmailPrivacy {
+	case EmailPrivacyHide:
+		return ""
+	case EmailPrivacyHash:
+		return fmt.Sprintf("%x", md5.Sum([]byte(strings.ToLower(strings.TrimSpace(email)))))
+	default:
+		return email
+	}
+}
@@ -0,0 +1,64 @@
+package compose
+
+// run: go test ./pkg/compose -run TestEmailPrivacy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailPrivacy(t *testing.T) {
+
+	aliases := map[string]string{"jane@private.example.com": "docs@example.com"}
+
+	tests := []struct {
+		privacy string
+		email   string
+		want    string
+	}{
+		{"", "joe@example.com", "joe@example.com"},
+		{EmailPrivacyShow, "joe@example.com", "joe@example.com"},
+		{EmailPrivacyHide, "joe@example.com", ""},
+		// Gravatar hash of the trimmed, lower case email
+		{EmailPrivacyHash, " MyEmailAddress@example.com ", "0bc83cb571cd1c50ba6f3e8a78ef1346"},
+		{EmailPrivacyHide, "Jane@Private.example.com", "docs@example.com"},
+		{EmailPrivacyHash, "", ""},
+	}
+	for _, tt := range tests {
+		config := &Config{EmailPrivacy: tt.privacy, EmailAliases: aliases}
+		assert.NoError(t, config.checkEmailPrivacy())
+		assert.Equal(t, tt.want, config.getPublicEmail(tt.email), "Email %s with privacy '%s'", tt.email, tt.privacy)
+	}
+
+	t.Run("Without config", func(t *testing.T) {
+		var config *Config
+		assert.Equal(t, "joe@example.com", config.getPublicEmail("joe@example.com"))
+	})
+
+	t.Run("Unknown privacy", func(t *testing.T) {
+		config := &Config{EmailPrivacy: "obfuscate"}
+		assert.Error(t, config.checkEmailPrivacy())
+	})
+
+	t.Run("Hidden emails in frontmatter", func(t *testing.T) {
+		file := &OriginFile{
+			LocalPath:    "localpath",
+			RemotePath:   "remotepath",
+			parentOrigin: &Origin{URL: "http://gitrepo.git", config: &Config{EmailPrivacy: EmailPrivacyHide}},
+			Commit: &OriginFileCommit{
+				Hash:   "abc",
+				Author: OriginFileCommitter{Email: "mail@mail.com", Name: "commiter name"},
+				Contributors: []OriginFileContributor{
+					{Name: "commiter name", Email: "mail@mail.com", Commits: 1},
+				},
+			},
+		}
+
+		result, err := file.ExpandFrontmatter("# Document")
+		assert.NoError(t, err)
+		assert.NotContains(t, result, "mail@mail.com")
+		assert.NotContains(t, result, "MonakoGitLastCommitAuthorEmail")
+		assert.Equal(t, "mail@mail.com", file.Commit.Contributors[0].Email, "Commit info is not changed")
+	})
+}
@@ -0,0 +1,231 @@
+package compose
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/snipem/monako/pkg/helpers"
+	"gopkg.in/yaml.v2"
+)
+
+// sectionIndexName is the name of the Hugo section index page without extension
+const sectionIndexName = "_index"
+
+// promotedIndexNames are the names of documents promoted to section indexes, the first wins
+var promotedIndexNames = []string{"index", "readme"}
+
+// promoteIndexDocuments makes an index or README document the section index of its directory,
+// if the origin promotes them and the directory has no section index
+func (origin *Origin) promoteIndexDocuments() {
+
+	if !origin.PromoteIndex {
+		return
+	}
+
+	// Documents by their local directory
+	dirs := map[string][]*OriginFile{}
+	for i := range origin.Files {
+		file := &origin.Files[i]
+		if file.GetFormat() != "" {
+			dir := filepath.Dir(file.LocalPath)
+			dirs[dir] = append(dirs[dir], file)
+		}
+	}
+
+	for dir, files := range dirs {
+		if filepath.Clean(dir) == filepath.Clean(origin.config.ContentWorkingDir) {
+			// A section index in the content dir would replace the home page
+			continue
+		}
+
+		var promoted *OriginFile
+		rank := len(promotedIndexNames)
+		for _, file := range files {
+			name := strings.ToLower(strings.TrimSuffix(filepath.Base(file.LocalPath), filepath.Ext(file.LocalPath)))
+			if name == sectionIndexName {
+				promoted = nil
+				break
+			}
+			for i, promotedName := range promotedIndexNames {
+				if name == promotedName && i < rank {
+					promoted, rank = file, i
+				}
+			}
+		}
+
+		if promoted != nil {
+			promoted.LocalPath = filepath.Join(dir, sectionIndexName+filepath.Ext(promoted.LocalPath))
+		}
+	}
+}
+
+// getSectionTitle returns the title of the origin if the file is the section index of its target dir
+func (file *OriginFile) getSectionTitle() string {
+	origin := file.parentOrigin
+	if origin == nil || origin.Title == "" || origin.config == nil {
+		return ""
+	}
+	name := filepath.Base(file.LocalPath)
+	if strings.TrimSuffix(name, filepath.Ext(name)) != sectionIndexName {
+		return ""
+	}
+	if filepath.Dir(file.LocalPath) != filepath.Join(origin.config.ContentWorkingDir, origin.TargetDir) {
+		return ""
+	}
+	return origin.Title
+}
+
+// writeSectionIndexes writes a section index page to every composed directory without one. The
+// title is the title of the origin composed to the directory or the prettified directory name.
+func (config *Config) writeSectionIndexes() error {
+
+	if config.DisableSectionIndex {
+		return nil
+	}
+
+	dirs, err := config.getDirsWithoutSectionIndex()
+	if err != nil {
+		return err
+	}
+
+	var tree *menuNode
+	if config.ListSectionPages {
+		tree, err = config.getMenuTree()
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error listing section pages"))
+		}
+	}
+
+	titles := config.getSectionTitles()
+	for _, dir := range dirs {
+		title, found := titles[dir]
+		if !found {
+			title = prettifyName(path.Base(dir))
+		}
+		titles[dir] = title
+
+		// Listings link to the written section indexes of the sections without page
+		if tree != nil {
+			if section := tree.findSection(dir); section != nil && section != tree && section.page == "" {
+				section.page = path.Join(dir, sectionIndexName+".md")
+				section.label = title
+			}
+		}
+	}
+
+	for _, dir := range dirs {
+
+		title := titles[dir]
+
+		frontmatter, err := yaml.Marshal(yaml.MapSlice{{Key: "title", Value: title}})
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error creating section index of %s", dir))
+		}
+
+		var page strings.Builder
+		page.WriteString("---\n")
+		page.Write(frontmatter)
+		page.WriteString("---\n")
+		if tree != nil {
+			if section := tree.findSection(dir); section != nil {
+				// Only the direct pages and sections are listed
+				children := &menuNode{}
+				for _, child := range section.children {
+					shallow := *child
+					shallow.children = nil
+					children.children = append(children.children, &shallow)
+				}
+				page.WriteString("\n")
+				children.render(&page, 0)
+			}
+		}
+
+		localPath := filepath.Join(config.ContentWorkingDir, filepath.FromSlash(dir), sectionIndexName+".md")
+		err = ioutil.WriteFile(localPath, []byte(page.String()), standardFilemode)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error writing section index %s", localPath))
+		}
+	}
+	return nil
+}
+
+// getDirsWithoutSectionIndex returns the slash separated directories of the content dir, that contain
+// documents and have no section index. Leaf bundles with an index document and their directories
+// are no sections.
+func (config *Config) getDirsWithoutSectionIndex() ([]string, error) {
+
+	sections := map[string]bool{}
+	indexed := map[string]bool{}
+	var bundles []string
+
+	err := filepath.Walk(config.ContentWorkingDir, func(localPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == monakoMenuDirectory {
+			return filepath.SkipDir
+		}
+		if info.IsDir() || !(helpers.IsMarkdown(localPath) || helpers.IsAsciidoc(localPath)) {
+			return nil
+		}
+
+		contentPath, err := config.getContentPath(localPath)
+		if err != nil {
+			return err
+		}
+
+		dir := path.Dir(contentPath)
+		switch strings.TrimSuffix(path.Base(contentPath), path.Ext(contentPath)) {
+		case sectionIndexName:
+			indexed[dir] = true
+		case "index":
+			bundles = append(bundles, dir)
+		}
+
+		for ; dir != "."; dir = path.Dir(dir) {
+			sections[dir] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error finding sections in %s", config.ContentWorkingDir))
+	}
+
+	var dirs []string
+	for dir := range sections {
+		if indexed[dir] || isInBundle(dir, bundles) {
+			continue
+		}
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// getSectionTitles returns the titles of the origins by their slash separated target dir
+func (config *Config) getSectionTitles() map[string]string {
+	titles := map[string]string{}
+	for _, origin := range config.Origins {
+		dir := strings.Trim(path.Clean(filepath.ToSlash(origin.TargetDir)), "/")
+		if _, found := titles[dir]; origin.Title != "" && !found {
+			titles[dir] = origin.Title
+		}
+	}
+	return titles
+}
+
+// isInBundle returns true if the directory is one of the bundles or below one of them
+func isInBundle(dir string, bundles []string) bool {
+	for _, bundle := range bundles {
+		if dir == bundle || strings.HasPrefix(dir, bundle+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package compose
+
+// run: go test ./pkg/compose -run TestSectionIndexes
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSectionIndexes(t *testing.T) {
+
+	monako := *NewOrigin("https://github.com/snipem/monako.git", "master", "docs", "docs/monako")
+	monako.Title = "Monako Documentation"
+	monako.PromoteIndex = true
+	config, _ := getTestConfig(t,
+		monako,
+		*NewOrigin("https://github.com/snipem/psnprices.git", "master", ".", "docs/psnprices"),
+	)
+
+	addComposedTestFiles(t, &config.Origins[0], map[string]string{
+		"docs/README.md":             "# Monako",
+		"docs/usage.md":              "# Usage",
+		"docs/guide/README.md":       "# Guide",
+		"docs/guide/index.adoc":      "= Guide",
+		"docs/api/_index.md":         "# API",
+		"docs/api/README.md":         "# API Readme",
+		"docs/bundle/index.md":       "# Bundle",
+		"docs/bundle/nested/page.md": "# Page",
+	})
+	addComposedTestFiles(t, &config.Origins[1], map[string]string{
+		"getting-started.md": "# Getting started",
+	})
+
+	t.Run("Promote index documents", func(t *testing.T) {
+		origin := &config.Origins[0]
+		origin.promoteIndexDocuments()
+
+		localPaths := map[string]string{}
+		for _, file := range origin.Files {
+			localPath, err := filepath.Rel(config.ContentWorkingDir, file.LocalPath)
+			assert.NoError(t, err)
+			localPaths[file.RemotePath] = filepath.ToSlash(localPath)
+		}
+		assert.Equal(t, "docs/monako/_index.md", localPaths["docs/README.md"])
+		assert.Equal(t, "docs/monako/guide/_index.adoc", localPaths["docs/guide/index.adoc"], "Index wins over README")
+		assert.Equal(t, "docs/monako/guide/README.md", localPaths["docs/guide/README.md"])
+		assert.Equal(t, "docs/monako/api/README.md", localPaths["docs/api/README.md"], "Existing section index is kept")
+		assert.Equal(t, "docs/monako/bundle/_index.md", localPaths["docs/bundle/index.md"])
+
+		for _, file := range origin.Files {
+			if file.RemotePath == "docs/README.md" {
+				assert.Equal(t, "Monako Documentation", file.getSectionTitle())
+			} else {
+				assert.Empty(t, file.getSectionTitle(), file.RemotePath)
+			}
+		}
+	})
+
+}
+
+func TestSectionTitle(t *testing.T) {
+
+	monako := *NewOrigin("https://github.com/snipem/monako.git", "master", "docs", "docs/monako")
+	monako.Title = "Monako Documentation"
+	commuteTube := *NewOrigin("https://github.com/snipem/commute-tube.git", "master", ".", "docs/commute-tube")
+	commuteTube.Title = "Commute Tube"
+	config, _ := getTestConfig(t, monako, commuteTube)
+
+	origin := &config.Origins[0]
+	file := origin.newFile("docs/README.md")
+	file.LocalPath = filepath.Join(filepath.Dir(file.LocalPath), "_index.md")
+
+	content, err := file.ExpandFrontmatter("---\ntitle: Readme\nweight: 1\n---\n# Monako")
+	assert.NoError(t, err)
+	assert.Contains(t, content, "title: Monako Documentation", "The title of the origin wins")
+	assert.NotContains(t, content, "Readme")
+	assert.Contains(t, content, "weight: 1")
+
+	assert.NoError(t, os.MkdirAll(filepath.Dir(file.LocalPath), standardFilemode))
+	assert.NoError(t, ioutil.WriteFile(file.LocalPath, []byte(content), standardFilemode))
+	origin.Files = append(origin.Files, file)
+	addComposedTestFiles(t, &config.Origins[1], map[string]string{"usage.md": "# Usage"})
+
+	tree, err := config.getMenuTree()
+	assert.NoError(t, err)
+
+	var menu strings.Builder
+	tree.render(&menu, 0)
+	assert.Equal(t, `- **Docs**
+  - [**Monako Documentation**]({{< relref "/docs/monako/_index.md" >}})
+  - **Commute Tube**
+    - [Usage]({{< relref "/docs/commute-tube/usage.md" >}})
+`, menu.String(), "Menu labels are the titles of the section index pages")
+}
+
+func TestWriteSectionIndexes(t *testing.T) {
+
+	monako := *NewOrigin("https://github.com/snipem/monako.git", "master", "docs", "docs/monako")
+	monako.Title = "Monako Documentation"
+	config, _ := getTestConfig(t,
+		monako,
+		*NewOrigin("https://github.com/snipem/psnprices.git", "master", ".", "docs/psnprices"),
+	)
+	config.ListSectionPages = true
+
+	addComposedTestFiles(t, &config.Origins[0], map[string]string{
+		"docs/usage.md":              "# Usage",
+		"docs/guide/setup.md":        "# Setup",
+		"docs/api/_index.md":         "# API",
+		"docs/bundle/index.md":       "# Bundle",
+		"docs/bundle/nested/page.md": "# Page",
+	})
+	addComposedTestFiles(t, &config.Origins[1], map[string]string{
+		"getting-started.md": "# Getting started",
+	})
+
+	err := config.writeSectionIndexes()
+	assert.NoError(t, err)
+
+	for dir, want := range map[string]string{
+		"docs": "---\ntitle: Docs\n---\n\n" +
+			"- [**Monako Documentation**]({{< relref \"/docs/monako/_index.md\" >}})\n" +
+			"- [**Psnprices**]({{< relref \"/docs/psnprices/_index.md\" >}})\n",
+		"docs/monako": "---\ntitle: Monako Documentation\n---\n\n" +
+			"- [**Api**]({{< relref \"/docs/monako/api/_index.md\" >}})\n" +
+			"- [**Bundle**]({{< relref \"/docs/monako/bundle/index.md\" >}})\n" +
+			"- [**Guide**]({{< relref \"/docs/monako/guide/_index.md\" >}})\n" +
+			"- [Usage]({{< relref \"/docs/monako/usage.md\" >}})\n",
+		"docs/psnprices":    "---\ntitle: Psnprices\n---\n\n- [Getting started]({{< relref \"/docs/psnprices/getting-started.md\" >}})\n",
+		"docs/monako/guide": "---\ntitle: Guide\n---\n\n- [Setup]({{< relref \"/docs/monako/guide/setup.md\" >}})\n",
+		"docs/monako/api":   "# API",
+	} {
+		index, err := ioutil.ReadFile(filepath.Join(config.ContentWorkingDir, dir, "_index.md"))
+		assert.NoError(t, err, dir)
+		assert.Equal(t, want, string(index), dir)
+	}
+
+	assert.NoFileExists(t, filepath.Join(config.ContentWorkingDir, "docs/monako/bundle/_index.md"), "Leaf bundles are no sections")
+	assert.NoFileExists(t, filepath.Join(config.ContentWorkingDir, "docs/monako/bundle/nested/_index.md"), "Leaf bundles are no sections")
+	assert.NoFileExists(t, filepath.Join(config.ContentWorkingDir, "_index.md"), "Home page is not replaced")
+
+	t.Run("Disabled", func(t *testing.T) {
+		config, _ := getTestConfig(t, *NewOrigin("https://github.com/snipem/monako.git", "master", "docs", "docs/monako"))
+		config.DisableSectionIndex = true
+		addComposedTestFiles(t, &config.Origins[0], map[string]string{"docs/usage.md": "# Usage"})
+
+		assert.NoError(t, config.writeSectionIndexes())
+		assert.NoFileExists(t, filepath.Join(config.ContentWorkingDir, "docs/monako/_index.md"))
+	})
+}
+
+func TestGetPagePath(t *testing.T) {
+	assert.Equal(t, "docs/page.html", getPagePath("docs/page.md"))
+	assert.Equal(t, "docs.html", getPagePath("docs/_index.md"))
+	assert.Equal(t, "/docs/guide.html", getPageURL("content", filepath.Join("content", "docs", "guide", "_index.adoc")))
+}
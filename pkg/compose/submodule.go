@@ -0,0 +1,146 @@
+package compose
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4"
+)
+
+// originSubmodule is a checked out submodule of an origin, possibly nested in another submodule
+type originSubmodule struct {
+	// Path is the path of the submodule in the origin repository
+	Path string
+	// URL is the URL of the submodule repository
+	URL string
+	// Commit is the commit the submodule is checked out at
+	Commit string
+
+	repo *git.Repository
+}
+
+// updateSubmodules clones the submodules of the repository recursively into the worktree
+// and stores them for obtaining commit information of their files
+func (origin *Origin) updateSubmodules(repo *git.Repository) error {
+
+	origin.submodules = nil
+	if !origin.Submodules {
+		return nil
+	}
+
+	err := origin.updateSubmodulesOf(repo, "", origin.URL)
+	if err != nil {
+		return err
+	}
+
+	// Nested submodules first, so files are found in their innermost submodule
+	sort.SliceStable(origin.submodules, func(i, j int) bool {
+		return len(origin.submodules[i].Path) > len(origin.submodules[j].Path)
+	})
+	return nil
+}
+
+// updateSubmodulesOf updates the submodules of a repository located at the path in the origin
+func (origin *Origin) updateSubmodulesOf(repo *git.Repository, repoPath string, repoURL string) error {
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error opening worktree of %s", repoURL))
+	}
+
+	submodules, err := worktree.Submodules()
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error reading submodules of %s", repoURL))
+	}
+
+	for _, submodule := range submodules {
+
+		submoduleConfig := submodule.Config()
+		submoduleConfig.URL = resolveSubmoduleURL(repoURL, submoduleConfig.URL)
+		submodulePath := path.Join(repoPath, submoduleConfig.Path)
+
+		origin.printf("Updating submodule '%s' from '%s' ...\n", submodulePath, submoduleConfig.URL)
+
+		// Use the same credentials, but the authentication method matching the URL of the submodule
+		submoduleOrigin := *origin
+		submoduleOrigin.URL = submoduleConfig.URL
+		auth, err := submoduleOrigin.getAuth()
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error creating authentication for submodule %s", submodulePath))
+		}
+
+		err = submodule.Update(&git.SubmoduleUpdateOptions{
+			Init: true,
+			Auth: auth,
+		})
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error updating submodule %s", submodulePath))
+		}
+
+		submoduleRepo, err := submodule.Repository()
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error opening submodule %s", submodulePath))
+		}
+
+		head, err := submoduleRepo.Head()
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error resolving HEAD of submodule %s", submodulePath))
+		}
+
+		origin.submodules = append(origin.submodules, &originSubmodule{
+			Path:   submodulePath,
+			URL:    submoduleConfig.URL,
+			Commit: head.Hash().String(),
+			repo:   submoduleRepo,
+		})
+		origin.getLogger().Debugf("Submodule %s of %s is at %s", submodulePath, origin.URL, head.Hash())
+
+		err = origin.updateSubmodulesOf(submoduleRepo, submodulePath, submoduleConfig.URL)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getSubmodule returns the innermost submodule containing the remote path, nil if the
+// file is part of the origin repository itself
+func (origin *Origin) getSubmodule(remotePath string) *originSubmodule {
+	for _, submodule := range origin.submodules {
+		if strings.HasPrefix(remotePath, submodule.Path+"/") {
+			return submodule
+		}
+	}
+	return nil
+}
+
+// resolveSubmoduleURL resolves submodule URLs like "../shared.git" relative to the URL of the
+// superproject, like Git does
+func resolveSubmoduleURL(superprojectURL string, submoduleURL string) string {
+
+	if !strings.HasPrefix(submoduleURL, "./") && !strings.HasPrefix(submoduleURL, "../") {
+		return submoduleURL
+	}
+
+	base := strings.TrimSuffix(superprojectURL, "/")
+	for {
+		switch {
+		case strings.HasPrefix(submoduleURL, "./"):
+			submoduleURL = strings.TrimPrefix(submoduleURL, "./")
+		case strings.HasPrefix(submoduleURL, "../"):
+			submoduleURL = strings.TrimPrefix(submoduleURL, "../")
+			// SCP like URLs as "git@github.com:org/repo.git" have a colon as first separator
+			if i := strings.LastIndexAny(base, "/:"); i >= 0 {
+				base = strings.TrimSuffix(base[:i+1], "/")
+			}
+		default:
+			if strings.HasSuffix(base, ":") {
+				return base + submoduleURL
+			}
+			return base + "/" + submoduleURL
+		}
+	}
+}
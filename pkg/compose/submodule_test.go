@@ -0,0 +1,110 @@
+package compose
+
+// run: go test ./pkg/compose -run TestSubmodule
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	"gopkg.in/src-d/go-git.v4/plumbing/format/index"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+func TestSubmodules(t *testing.T) {
+
+	tempdir := GetLocalTempDir(t)
+	sharedDir := createTestRepositoryAt(t, filepath.Join(tempdir, "shared"), map[string]string{
+		"chapter.md": "# Shared Chapter",
+	})
+	sharedCommit := commitTestFiles(t, sharedDir, map[string]string{
+		"chapter.md": "# Shared Chapter, second edition",
+	})
+
+	repoDir := createTestRepositoryAt(t, filepath.Join(tempdir, "main"), map[string]string{
+		"docs/README.md": "# Main",
+	})
+	addTestSubmodule(t, repoDir, "docs/shared", "../shared", sharedCommit)
+
+	for _, cacheDir := range []string{"", filepath.Join(tempdir, "cache")} {
+		t.Run(fmt.Sprintf("Cache dir '%s'", cacheDir), func(t *testing.T) {
+			origin := NewOrigin(repoDir, "master", "docs", "docs/main")
+			origin.Submodules = true
+			config, _ := getTestConfig(t, *origin)
+			config.CacheDir = cacheDir
+
+			err := config.Compose()
+			assert.NoError(t, err)
+
+			content, err := ioutil.ReadFile(filepath.Join(config.ContentWorkingDir, "docs/main/shared/chapter.md"))
+			assert.NoError(t, err)
+			assert.Contains(t, string(content), "# Shared Chapter, second edition")
+			assert.Contains(t, string(content), "MonakoGitLastCommitHash: "+sharedCommit.String(), "Commit info of the submodule")
+			assert.Contains(t, string(content), "MonakoGitRemotePath: chapter.md")
+			assert.Contains(t, string(content), "MonakoGitRef: "+sharedCommit.String())
+			assert.Contains(t, string(content), "MonakoGitRemote: "+filepath.Join(tempdir, "shared"), "Relative URL is resolved")
+		})
+	}
+
+	t.Run("Submodules are opt-in", func(t *testing.T) {
+		origin := NewOrigin(repoDir, "master", "docs", "docs/main")
+		config, _ := getTestConfig(t, *origin)
+
+		err := config.Compose()
+		assert.NoError(t, err)
+		assert.NoFileExists(t, filepath.Join(config.ContentWorkingDir, "docs/main/shared/chapter.md"))
+	})
+}
+
+func TestResolveSubmoduleURL(t *testing.T) {
+	assert.Equal(t, "https://github.com/org/shared.git", resolveSubmoduleURL("https://github.com/org/repo.git", "../shared.git"))
+	assert.Equal(t, "https://github.com/other/shared.git", resolveSubmoduleURL("https://github.com/org/repo/", "../../other/shared.git"))
+	assert.Equal(t, "https://github.com/org/repo.git/sub", resolveSubmoduleURL("https://github.com/org/repo.git", "./sub"))
+	assert.Equal(t, "git@github.com:org/shared.git", resolveSubmoduleURL("git@github.com:org/repo.git", "../shared.git"))
+	assert.Equal(t, "git@github.com:shared.git", resolveSubmoduleURL("git@github.com:org/repo.git", "../../shared.git"))
+	assert.Equal(t, "https://gitlab.com/shared.git", resolveSubmoduleURL("https://github.com/org/repo.git", "https://gitlab.com/shared.git"))
+}
+
+// createTestRepositoryAt creates a Git repository in the dir with the committed files
+func createTestRepositoryAt(t testing.TB, repoDir string, files map[string]string) string {
+	_, err := git.PlainInit(repoDir, false)
+	assert.NoError(t, err)
+
+	commitTestFiles(t, repoDir, files)
+	return repoDir
+}
+
+// addTestSubmodule commits a submodule at the commit to the repository
+func addTestSubmodule(t *testing.T, repoDir string, submodulePath string, url string, commit plumbing.Hash) {
+
+	repo, err := git.PlainOpen(repoDir)
+	assert.NoError(t, err)
+	worktree, err := repo.Worktree()
+	assert.NoError(t, err)
+
+	gitmodules := fmt.Sprintf("[submodule \"%s\"]\n\tpath = %s\n\turl = %s\n", submodulePath, submodulePath, url)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(repoDir, ".gitmodules"), []byte(gitmodules), standardFilemode))
+	_, err = worktree.Add(".gitmodules")
+	assert.NoError(t, err)
+
+	// go-git can't add submodules, so the gitlink is added to the index directly
+	idx, err := repo.Storer.Index()
+	assert.NoError(t, err)
+	idx.Entries = append(idx.Entries, &index.Entry{
+		Name: submodulePath,
+		Hash: commit,
+		Mode: filemode.Submodule,
+	})
+	assert.NoError(t, repo.Storer.SetIndex(idx))
+
+	_, err = worktree.Commit("Add submodule", &git.CommitOptions{
+		Author: &object.Signature{Name: "Monako Test", Email: "monako@example.com", When: time.Now()},
+	})
+	assert.NoError(t, err)
+}
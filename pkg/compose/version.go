@@ -0,0 +1,352 @@
+package compose
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/snipem/monako/pkg/helpers"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
+	"gopkg.in/yaml.v2"
+)
+
+// latestVersionAlias is the directory name that always points to the newest version
+const latestVersionAlias = "latest"
+
+// versionGroup contains all versions composed from a single versioned origin
+type versionGroup struct {
+	// TargetDir is the target dir of the versioned origin, the versions are composed below it
+	TargetDir string
+	// Latest is the name of the newest version
+	Latest string
+
+	versions []*originVersion
+}
+
+// originVersion is a single version of a versioned origin
+type originVersion struct {
+	// Name is the name of the version and its directory below the target dir of the group
+	Name string
+
+	group  *versionGroup
+	origin *Origin
+}
+
+// versionData is the version metadata of a version group that is provided to the theme
+type versionData struct {
+	Latest   string             `yaml:"latest"`
+	Versions []versionDataEntry `yaml:"versions"`
+}
+
+// versionDataEntry is a single version in the version metadata
+type versionDataEntry struct {
+	Name   string `yaml:"name"`
+	URL    string `yaml:"url"`
+	Latest bool   `yaml:"latest"`
+}
+
+// isVersioned returns true if multiple versions should be composed from this origin
+func (origin *Origin) isVersioned() bool {
+	return len(origin.Versions) > 0 || origin.VersionPattern != ""
+}
+
+// expandVersions replaces every versioned origin by one origin per version. Each version is
+// composed into its own sub directory of the target dir of the versioned origin.
+func (config *Config) expandVersions() error {
+
+	var origins []Origin
+	for _, origin := range config.Origins {
+
+		if !origin.isVersioned() {
+			origins = append(origins, origin)
+			continue
+		}
+
+		if !origin.isGit() {
+			return fmt.Errorf("Only Git origins can be versioned, %s is of type '%s'", origin.URL, origin.Type)
+		}
+
+		if origin.Branch != "" || origin.Tag != "" || origin.Commit != "" {
+			return fmt.Errorf("Versioned origin %s can't have a branch, tag or commit", origin.URL)
+		}
+
+		refs, err := origin.resolveVersions()
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error resolving versions of %s", origin.URL))
+		}
+
+		group := &versionGroup{TargetDir: origin.TargetDir}
+		for _, ref := range refs {
+
+			versioned := origin
+			versioned.Versions = nil
+			versioned.VersionPattern = ""
+			if ref.IsTag() {
+				versioned.Tag = ref.Short()
+			} else {
+				versioned.Branch = ref.Short()
+			}
+
+			name := getVersionName(ref)
+			if name == latestVersionAlias {
+				return fmt.Errorf("Version '%s' of %s can't be named like the alias of the latest version", ref.Short(), origin.URL)
+			}
+			versioned.TargetDir = path.Join(origin.TargetDir, name)
+			versioned.version = &originVersion{Name: name, group: group}
+
+			if group.Latest == "" || name == getVersionName(plumbing.ReferenceName(origin.LatestVersion)) {
+				group.Latest = name
+			}
+
+			origins = append(origins, versioned)
+		}
+		fmt.Printf("Composing %d versions of '%s', latest is '%s'\n", len(refs), origin.URL, group.Latest)
+	}
+
+	config.Origins = origins
+
+	// Link versions to their final origins, after the origins slice won't change anymore
+	for i := range config.Origins {
+		if version := config.Origins[i].version; version != nil {
+			version.origin = &config.Origins[i]
+		}
+	}
+	for _, group := range config.getVersionGroups() {
+		group.versions = nil
+	}
+	for i := range config.Origins {
+		if version := config.Origins[i].version; version != nil {
+			version.group.versions = append(version.group.versions, version)
+		}
+	}
+
+	return nil
+}
+
+// resolveVersions returns the branches and tags of the remote that are configured as versions.
+// The newest version is the first one.
+func (origin *Origin) resolveVersions() ([]plumbing.ReferenceName, error) {
+
+	auth, err := origin.getAuth()
+	if err != nil {
+		return nil, err
+	}
+
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: git.DefaultRemoteName,
+		URLs: []string{origin.URL},
+	})
+
+	remoteRefs, err := remote.List(&git.ListOptions{Auth: auth})
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Error listing references of %s", origin.URL))
+	}
+
+	available := map[plumbing.ReferenceName]bool{}
+	for _, ref := range remoteRefs {
+		available[ref.Name()] = true
+	}
+
+	var refs []plumbing.ReferenceName
+
+	for _, version := range origin.Versions {
+		switch {
+		case available[plumbing.NewTagReferenceName(version)]:
+			refs = append(refs, plumbing.NewTagReferenceName(version))
+		case available[plumbing.NewBranchReferenceName(version)]:
+			refs = append(refs, plumbing.NewBranchReferenceName(version))
+		default:
+			return nil, fmt.Errorf("Version '%s' is neither a branch nor a tag of %s", version, origin.URL)
+		}
+	}
+
+	if origin.VersionPattern != "" {
+		for _, ref := range remoteRefs {
+			name := ref.Name()
+			if !name.IsTag() || strings.HasSuffix(name.String(), "^{}") {
+				continue
+			}
+			matched, err := path.Match(origin.VersionPattern, name.Short())
+			if err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("Invalid version pattern '%s'", origin.VersionPattern))
+			}
+			if matched && !containsReference(refs, name) {
+				refs = append(refs, name)
+			}
+		}
+	}
+
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("No versions found in %s", origin.URL)
+	}
+
+	sort.SliceStable(refs, func(i, j int) bool {
+		return helpers.CompareVersions(refs[i].Short(), refs[j].Short()) > 0
+	})
+
+	if origin.LatestVersion != "" && !containsReference(refs, plumbing.NewTagReferenceName(origin.LatestVersion)) &&
+		!containsReference(refs, plumbing.NewBranchReferenceName(origin.LatestVersion)) {
+		return nil, fmt.Errorf("Latest version '%s' is not one of the versions of %s", origin.LatestVersion, origin.URL)
+	}
+
+	return refs, nil
+}
+
+// containsReference returns true if the reference is in the list
+func containsReference(refs []plumbing.ReferenceName, ref plumbing.ReferenceName) bool {
+	for _, r := range refs {
+		if r == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// getVersionName returns the directory name of a version, branches like "release/1.0" become "release-1.0"
+func getVersionName(ref plumbing.ReferenceName) string {
+	return strings.Replace(ref.Short(), "/", "-", -1)
+}
+
+// getVersionGroups returns all version groups of the config in the order of the origins
+func (config *Config) getVersionGroups() []*versionGroup {
+	var groups []*versionGroup
+	seen := map[*versionGroup]bool{}
+	for _, origin := range config.Origins {
+		if origin.version != nil && !seen[origin.version.group] {
+			seen[origin.version.group] = true
+			groups = append(groups, origin.version.group)
+		}
+	}
+	return groups
+}
+
+// getVersionParams returns the frontmatter parameters of a versioned file. The files of the
+// latest version get an alias in the "latest" directory of the version group.
+func (file *OriginFile) getVersionParams() yaml.MapSlice {
+
+	version := file.parentOrigin.version
+	if version == nil {
+		return nil
+	}
+
+	params := yaml.MapSlice{
+		{Key: "MonakoVersion", Value: version.Name},
+		{Key: "MonakoVersionGroup", Value: version.group.TargetDir},
+		{Key: "MonakoVersionLatest", Value: version.group.Latest},
+	}
+
+	if version.Name == version.group.Latest {
+		versionDir := filepath.Join(file.parentOrigin.config.ContentWorkingDir, file.parentOrigin.TargetDir)
+		aliasDir := filepath.Join(file.parentOrigin.config.ContentWorkingDir, version.group.TargetDir, latestVersionAlias)
+		relativePath, err := filepath.Rel(versionDir, file.LocalPath)
+		if err == nil {
+			params = append(params, yaml.MapItem{
+				Key:   "aliases",
+				Value: []string{getPageURL(file.parentOrigin.config.ContentWorkingDir, filepath.Join(aliasDir, relativePath))},
+			})
+		}
+	}
+
+	return params
+}
+
+// linkVersions writes the version metadata for the theme and links the pages of older
+// versions to the same page in the latest version
+func (config *Config) linkVersions() error {
+
+	groups := config.getVersionGroups()
+	if len(groups) == 0 {
+		return nil
+	}
+
+	data := map[string]versionData{}
+	for _, group := range groups {
+
+		entry := versionData{Latest: group.Latest}
+		var latest *originVersion
+		for _, version := range group.versions {
+			entry.Versions = append(entry.Versions, versionDataEntry{
+				Name:   version.Name,
+				URL:    "/" + path.Join(filepath.ToSlash(group.TargetDir), version.Name) + "/",
+				Latest: version.Name == group.Latest,
+			})
+			if version.Name == group.Latest {
+				latest = version
+			}
+		}
+		data[group.TargetDir] = entry
+
+		err := config.addCanonicalURLs(group, latest)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Error linking versions of %s", group.TargetDir))
+		}
+	}
+
+	dataDir := filepath.Join(config.HugoWorkingDir, "data", "monako")
+	err := os.MkdirAll(dataDir, standardFilemode)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error creating data dir %s", dataDir))
+	}
+
+	content, err := yaml.Marshal(data)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error marshalling version data"))
+	}
+
+	dataFile := filepath.Join(dataDir, "versions.yaml")
+	err = ioutil.WriteFile(dataFile, content, standardFilemode)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error writing version data to %s", dataFile))
+	}
+	return nil
+}
+
+// addCanonicalURLs adds the URL of the same page in the latest version to all pages of the older versions
+func (config *Config) addCanonicalURLs(group *versionGroup, latest *originVersion) error {
+
+	if latest == nil {
+		return nil
+	}
+
+	latestDir := filepath.Join(config.ContentWorkingDir, latest.origin.TargetDir)
+	latestPages := map[string]string{}
+	for _, file := range latest.origin.Files {
+		if file.GetFormat() == "" {
+			continue
+		}
+		relativePath, err := filepath.Rel(latestDir, file.LocalPath)
+		if err == nil {
+			latestPages[relativePath] = getPageURL(config.ContentWorkingDir, file.LocalPath)
+		}
+	}
+
+	for _, version := range group.versions {
+		if version == latest {
+			continue
+		}
+
+		versionDir := filepath.Join(config.ContentWorkingDir, version.origin.TargetDir)
+		for _, file := range version.origin.Files {
+			relativePath, err := filepath.Rel(versionDir, file.LocalPath)
+			if err != nil || file.GetFormat() == "" || latestPages[relativePath] == "" {
+				continue
+			}
+
+			err = addFrontmatterParamsToFile(file.LocalPath, yaml.MapSlice{
+				{Key: "MonakoCanonicalURL", Value: latestPages[relativePath]},
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package compose
+
+// run: go test ./pkg/compose -run TestVersions
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/yaml.v2"
+)
+
+// createVersionedTestRepository returns a repository with the tags v1.0.0, v1.1.0 and v2.0.0
+// and a release branch
+func createVersionedTestRepository(t *testing.T) string {
+
+	repoDir := createTestRepository(t, map[string]string{
+		"README.md":  "# Version 1.0.0",
+		"removed.md": "# Removed in 2.0.0",
+	})
+	repo, err := git.PlainOpen(repoDir)
+	assert.NoError(t, err)
+
+	tag := func(name string) {
+		head, err := repo.Head()
+		assert.NoError(t, err)
+		_, err = repo.CreateTag(name, head.Hash(), nil)
+		assert.NoError(t, err)
+	}
+
+	tag("v1.0.0")
+	commitTestFiles(t, repoDir, map[string]string{"README.md": "# Version 1.1.0"})
+	tag("v1.1.0")
+
+	worktree, err := repo.Worktree()
+	assert.NoError(t, err)
+	_, err = worktree.Remove("removed.md")
+	assert.NoError(t, err)
+	commitTestFiles(t, repoDir, map[string]string{"README.md": "# Version 2.0.0"})
+	tag("v2.0.0")
+
+	head, err := repo.Head()
+	assert.NoError(t, err)
+	err = worktree.Checkout(&git.CheckoutOptions{Hash: head.Hash(), Branch: "refs/heads/release/3.0", Create: true})
+	assert.NoError(t, err)
+
+	return repoDir
+}
+
+func TestVersions(t *testing.T) {
+
+	repoDir := createVersionedTestRepository(t)
+
+	origin := NewOrigin(repoDir, "", ".", "docs/product")
+	origin.VersionPattern = "v*"
+	config, _ := getTestConfig(t, *origin)
+
+	err := config.Compose()
+	assert.NoError(t, err)
+
+	assert.Len(t, config.Origins, 3)
+	for _, version := range []string{"v1.0.0", "v1.1.0", "v2.0.0"} {
+		assert.FileExists(t, filepath.Join(config.ContentWorkingDir, "docs/product", version, "README.md"))
+	}
+	assert.NoFileExists(t, filepath.Join(config.ContentWorkingDir, "docs/product/v2.0.0/removed.md"))
+
+	t.Run("Latest version has alias", func(t *testing.T) {
+		content, err := ioutil.ReadFile(filepath.Join(config.ContentWorkingDir, "docs/product/v2.0.0/README.md"))
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), "MonakoVersion: v2.0.0")
+		assert.Contains(t, string(content), "MonakoVersionLatest: v2.0.0")
+		assert.Contains(t, string(content), "- /docs/product/latest/README.html")
+		assert.NotContains(t, string(content), "MonakoCanonicalURL")
+	})
+
+	t.Run("Older versions link to latest version", func(t *testing.T) {
+		content, err := ioutil.ReadFile(filepath.Join(config.ContentWorkingDir, "docs/product/v1.0.0/README.md"))
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), "MonakoCanonicalURL: /docs/product/v2.0.0/README.html")
+		assert.Contains(t, string(content), "# Version 1.0.0")
+		assert.NotContains(t, string(content), "aliases")
+
+		content, err = ioutil.ReadFile(filepath.Join(config.ContentWorkingDir, "docs/product/v1.0.0/removed.md"))
+		assert.NoError(t, err)
+		assert.NotContains(t, string(content), "MonakoCanonicalURL", "Page doesn't exist in latest version")
+	})
+
+	t.Run("Version data for theme", func(t *testing.T) {
+		content, err := ioutil.ReadFile(filepath.Join(config.HugoWorkingDir, "data/monako/versions.yaml"))
+		assert.NoError(t, err)
+
+		data := map[string]versionData{}
+		assert.NoError(t, yaml.Unmarshal(content, &data))
+		assert.Equal(t, versionData{
+			Latest: "v2.0.0",
+			Versions: []versionDataEntry{
+				{Name: "v2.0.0", URL: "/docs/product/v2.0.0/", Latest: true},
+				{Name: "v1.1.0", URL: "/docs/product/v1.1.0/"},
+				{Name: "v1.0.0", URL: "/docs/product/v1.0.0/"},
+			},
+		}, data["docs/product"])
+	})
+}
+
+func TestVersionsExplicit(t *testing.T) {
+
+	repoDir := createVersionedTestRepository(t)
+
+	origin := NewOrigin(repoDir, "", ".", "docs/product")
+	origin.Versions = []string{"v1.0.0", "release/3.0"}
+	origin.LatestVersion = "v1.0.0"
+	config, _ := getTestConfig(t, *origin)
+
+	err := config.Compose()
+	assert.NoError(t, err)
+
+	assert.Equal(t, "v1.0.0", config.Origins[0].Tag, "Versions are sorted newest first")
+	assert.Equal(t, "release/3.0", config.Origins[1].Branch)
+	assert.FileExists(t, filepath.Join(config.ContentWorkingDir, "docs/product/release-3.0/README.md"))
+
+	content, err := ioutil.ReadFile(filepath.Join(config.ContentWorkingDir, "docs/product/v1.0.0/README.md"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "- /docs/product/latest/README.html", "Latest version is configured")
+
+	t.Run("Fail on unknown version", func(t *testing.T) {
+		origin.Versions = []string{"v9.9.9"}
+		origin.LatestVersion = ""
+		config, _ := getTestConfig(t, *origin)
+		err := config.Compose()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "v9.9.9")
+	})
+
+	t.Run("Fail on version named like the latest alias", func(t *testing.T) {
+		repo, err := git.PlainOpen(repoDir)
+		assert.NoError(t, err)
+		head, err := repo.Head()
+		assert.NoError(t, err)
+		_, err = repo.CreateTag(latestVersionAlias, head.Hash(), nil)
+		assert.NoError(t, err)
+
+		origin.Versions = []string{"v1.0.0", latestVersionAlias}
+		origin.LatestVersion = ""
+		config, _ := getTestConfig(t, *origin)
+		err = config.Compose()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "alias of the latest version")
+	})
+
+	t.Run("Fail on versioned origin with branch", func(t *testing.T) {
+		origin := NewOrigin(repoDir, "master", ".", "docs/product")
+		origin.Versions = []string{"v1.0.0"}
+		config, _ := getTestConfig(t, *origin)
+		err := config.Compose()
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,121 @@
+package helpers
+
+import (
+	"path"
+	"strings"
+
+	"github.com/bmatcuk/doublestar"
+	"github.com/sirupsen/logrus"
+)
+
+// PathIsListed returns true if the slash separated path of a file is listed. Entries without
+// glob characters and slashes are suffixes of the file name, like in FileIsListed. All other
+// entries are doublestar glob patterns like "docs/**/*.md":
+//
+// - Patterns without a slash match the name of the file or of one of its parent directories
+// - Patterns with a slash match the full path of the file or of one of its parent directories
+// - Patterns ending with a slash like "node_modules/" only match directories
+// - Patterns starting with "!" negate the entry, the last matching entry wins
+func PathIsListed(filePath string, list []string) bool {
+	listed := false
+	for _, entry := range list {
+		negate := strings.HasPrefix(entry, "!")
+		if matchesPath(strings.TrimPrefix(entry, "!"), filePath, false) {
+			listed = !negate
+		}
+	}
+	return listed
+}
+
+// DirIsPruned returns true if a directory and everything below it is listed, so the directory
+// doesn't have to be walked. Directories are still walked if a later negation may match files
+// below them.
+func DirIsPruned(dirPath string, list []string) bool {
+	pruned := false
+	for _, entry := range list {
+		if strings.HasPrefix(entry, "!") {
+			if pruned && mayMatchBelow(strings.TrimPrefix(entry, "!"), dirPath) {
+				pruned = false
+			}
+			continue
+		}
+		if matchesPath(entry, dirPath, true) {
+			pruned = true
+		}
+	}
+	return pruned
+}
+
+// matchesPath returns true if the pattern matches the path or one of its parent directories
+func matchesPath(pattern string, filePath string, isDir bool) bool {
+
+	filePath = normalizePath(filePath)
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = normalizePath(strings.TrimSuffix(pattern, "/"))
+
+	if pattern == "" || filePath == "" {
+		return false
+	}
+
+	// Backward compatible suffix lists like ".md" only match files
+	if !dirOnly && !isGlob(pattern) && !strings.Contains(pattern, "/") {
+		return !isDir && FileIsListed(path.Base(filePath), []string{pattern})
+	}
+
+	for candidate, candidateIsDir := filePath, isDir; candidate != "."; candidate, candidateIsDir = path.Dir(candidate), true {
+		if dirOnly && !candidateIsDir {
+			continue
+		}
+
+		name := candidate
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(candidate)
+		}
+
+		matched, err := doublestar.Match(pattern, name)
+		if err != nil {
+			logrus.Warnf("Invalid pattern '%s': %s", pattern, err)
+			return false
+		}
+		if matched {
+			return true
+		}
+
+		// "docs/internal/**" matches everything below the directory and therefore the directory itself
+		if candidateIsDir && strings.HasSuffix(pattern, "/**") {
+			matched, _ = doublestar.Match(strings.TrimSuffix(pattern, "/**"), name)
+			if matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// mayMatchBelow returns true if the pattern could match a file below the directory
+func mayMatchBelow(pattern string, dirPath string) bool {
+
+	pattern = normalizePath(strings.TrimSuffix(pattern, "/"))
+	if !strings.Contains(pattern, "/") {
+		// Names are matched in every directory
+		return true
+	}
+
+	prefix := pattern
+	if i := strings.IndexAny(pattern, "*?[{\\"); i >= 0 {
+		prefix = pattern[:i]
+	}
+	dirPrefix := normalizePath(dirPath) + "/"
+	return strings.HasPrefix(prefix, dirPrefix) || strings.HasPrefix(dirPrefix, prefix)
+}
+
+// isGlob returns true if the pattern contains glob characters
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[{\\")
+}
+
+// normalizePath removes leading "./" and "/" from a slash separated path
+func normalizePath(p string) string {
+	p = path.Clean("/" + p)
+	return strings.TrimPrefix(p, "/")
+}
@@ -0,0 +1,60 @@
+package helpers
+
+// run: go test ./pkg/helpers/
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathIsListed(t *testing.T) {
+
+	t.Run("Suffixes are backward compatible", func(t *testing.T) {
+		assert.True(t, PathIsListed("docs/README.md", []string{".md", ".adoc"}))
+		assert.True(t, PathIsListed("docs/README.MD", []string{".md"}))
+		assert.False(t, PathIsListed("docs.md/image.png", []string{".md"}))
+		assert.False(t, PathIsListed("docs/README.md", []string{}))
+	})
+
+	t.Run("Globs match the full path", func(t *testing.T) {
+		assert.True(t, PathIsListed("docs/guide/install.md", []string{"docs/**/*.md"}))
+		assert.True(t, PathIsListed("docs/install.md", []string{"docs/**/*.md"}))
+		assert.False(t, PathIsListed("other/install.md", []string{"docs/**/*.md"}))
+		assert.True(t, PathIsListed("docs/internal/secret/a.md", []string{"docs/internal/**"}))
+		assert.True(t, PathIsListed("./docs/install.md", []string{"/docs/*.md"}))
+	})
+
+	t.Run("Globs without slash match names", func(t *testing.T) {
+		assert.True(t, PathIsListed("docs/guide/install.md", []string{"*.md"}))
+		assert.False(t, PathIsListed("docs/vendor/lib/README.md", []string{"vendor"}), "Plain names are suffixes")
+		assert.True(t, PathIsListed("docs/vendor/lib/README.md", []string{"vendor/"}))
+		assert.True(t, PathIsListed("docs/vendor/lib/README.md", []string{"**/vendor"}))
+		assert.False(t, PathIsListed("docs/vendor", []string{"vendor/"}), "Directory patterns don't match files")
+	})
+
+	t.Run("Negations", func(t *testing.T) {
+		list := []string{"docs/internal/**", "!docs/internal/public.md"}
+		assert.True(t, PathIsListed("docs/internal/secret.md", list))
+		assert.False(t, PathIsListed("docs/internal/public.md", list))
+
+		assert.True(t, PathIsListed("docs/README.md", []string{"!*.md", "docs/*"}), "Last match wins")
+	})
+}
+
+func TestDirIsPruned(t *testing.T) {
+	assert.True(t, DirIsPruned("node_modules", []string{"node_modules/"}))
+	assert.True(t, DirIsPruned("web/node_modules", []string{"node_modules/"}))
+	assert.True(t, DirIsPruned(".github", []string{".github/"}))
+	assert.True(t, DirIsPruned("docs/internal", []string{"docs/internal/**"}))
+	assert.True(t, DirIsPruned("docs/internal/sub", []string{"docs/internal/**"}))
+	assert.False(t, DirIsPruned("docs", []string{"docs/internal/**"}))
+	assert.False(t, DirIsPruned("vendor", []string{"vendor", ".md"}), "Suffixes only match files")
+
+	t.Run("Negations below a directory keep it", func(t *testing.T) {
+		assert.False(t, DirIsPruned("docs/internal", []string{"docs/internal/**", "!docs/internal/public.md"}))
+		assert.False(t, DirIsPruned("docs/internal", []string{"docs/internal/**", "!*.md"}))
+		assert.True(t, DirIsPruned("docs/internal", []string{"docs/internal/**", "!other/*.md"}))
+		assert.True(t, DirIsPruned("docs/internal", []string{"!docs/internal/public.md", "docs/internal/**"}))
+	})
+}
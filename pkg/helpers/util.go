@@ -1,6 +1,8 @@
 package helpers
 
 import (
+	"regexp"
+	"strconv"
 	"strings"
 
 	hugo "github.com/gohugoio/hugo/commands"
@@ -42,3 +44,49 @@ func Trace() {
 	// This is slow
 	logrus.SetReportCaller(true)
 }
+
+// CompareVersions compares two version strings like "v1.10.0" and "v1.9.2" by their numeric
+// and non numeric parts. It returns a negative number if a is older than b, a positive number
+// if a is newer than b and 0 if they are equal
+func CompareVersions(a string, b string) int {
+	partsA := versionParts.FindAllString(strings.TrimPrefix(strings.ToLower(a), "v"), -1)
+	partsB := versionParts.FindAllString(strings.TrimPrefix(strings.ToLower(b), "v"), -1)
+
+	for i := 0; i < len(partsA) && i < len(partsB); i++ {
+		numberA, errA := strconv.Atoi(partsA[i])
+		numberB, errB := strconv.Atoi(partsB[i])
+
+		switch {
+		case errA == nil && errB == nil:
+			if numberA != numberB {
+				return numberA - numberB
+			}
+		case errA == nil:
+			// Numbers are newer than pre-release suffixes like "rc"
+			return 1
+		case errB == nil:
+			return -1
+		default:
+			if c := strings.Compare(partsA[i], partsB[i]); c != 0 {
+				return c
+			}
+		}
+	}
+
+	if len(partsA) == len(partsB) {
+		return 0
+	}
+
+	// A version with a suffix like "-rc1" is older than the plain version
+	longer, shorter, sign := partsA, partsB, 1
+	if len(partsB) > len(partsA) {
+		longer, shorter, sign = partsB, partsA, -1
+	}
+	if _, err := strconv.Atoi(longer[len(shorter)]); err != nil {
+		return -sign
+	}
+	return sign
+}
+
+// versionParts splits a version into its numeric and alphabetic parts
+var versionParts = regexp.MustCompile(`[0-9]+|[a-z]+`)
@@ -41,3 +41,13 @@ func TestTrace(t *testing.T) {
 	Trace()
 	assert.Equal(t, logrus.GetLevel(), logrus.DebugLevel)
 }
+
+func TestCompareVersions(t *testing.T) {
+	assert.True(t, CompareVersions("v1.10.0", "v1.9.2") > 0)
+	assert.True(t, CompareVersions("1.9.2", "v1.10.0") < 0)
+	assert.True(t, CompareVersions("v2", "v1.9") > 0)
+	assert.True(t, CompareVersions("v1.0.0", "v1.0.0-rc1") > 0, "Release is newer than release candidate")
+	assert.True(t, CompareVersions("v1.0.0-rc1", "v1.0.0-rc2") < 0)
+	assert.True(t, CompareVersions("v1.0.1", "v1.0") > 0)
+	assert.Equal(t, 0, CompareVersions("v1.2.3", "1.2.3"))
+}